@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/IskanderSh/hezzl-task/internal/services"
+	redis "github.com/IskanderSh/hezzl-task/internal/storage/cache"
+	"github.com/IskanderSh/hezzl-task/internal/storage/postgres"
+)
+
+// import loads goods from a CSV or NDJSON file into a project:
+//
+//	go run ./cmd/import --config=config/local.yaml --project=1 --file=goods.csv --mode=upsert --key=sku
+func main() {
+	projectID := flag.Int("project", 0, "project id to import goods into")
+	file := flag.String("file", "", "path to csv or ndjson file")
+	format := flag.String("format", "", "csv or ndjson, detected by file extension when empty")
+	mode := flag.String("mode", models.ImportModeInsert, "insert or upsert")
+	key := flag.String("key", models.ImportKeyName, "upsert key: name or sku")
+	dryRun := flag.Bool("dry-run", false, "validate and report without saving")
+	mapping := flag.String("map", "", "column mapping, e.g. name:Title,sku:Article")
+
+	// load config file, parses the flags above as well
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if *projectID <= 0 || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*file), ".")
+		if *format == "jsonl" {
+			*format = models.ImportFormatNDJSON
+		}
+	}
+
+	columns := make(map[string]string)
+	if *mapping != "" {
+		for _, pair := range strings.Split(*mapping, ",") {
+			field, column, ok := strings.Cut(pair, ":")
+			if !ok {
+				fail(log, fmt.Errorf("invalid mapping %s", pair))
+			}
+			columns[field] = column
+		}
+	}
+
+	source, err := os.Open(*file)
+	if err != nil {
+		fail(log, err)
+	}
+	defer source.Close()
+
+	ctx := context.Background()
+
+	storage, err := postgres.NewStorage(log, cfg.Storage)
+	if err != nil {
+		fail(log, err)
+	}
+
+	cache := redis.NewCache(log, cfg.Cache)
+	if err := cache.Ping(ctx); err != nil {
+		fail(log, err)
+	}
+
+	// the imported goods are logged like any other change
+	broker, err := services.NewNatsServer(log, cfg.MessageBroker)
+	if err != nil {
+		fail(log, err)
+	}
+
+	importService := services.NewImportService(log, storage, cache, broker)
+
+	report, err := importService.ImportGoods(ctx, &models.ImportRequest{
+		ProjectID: *projectID,
+		Format:    *format,
+		Mode:      *mode,
+		Key:       *key,
+		DryRun:    *dryRun,
+		Mapping:   columns,
+	}, source)
+	if err != nil {
+		fail(log, err)
+	}
+
+	if err := broker.Close(ctx); err != nil {
+		log.Warn("couldn't flush logs to nats", slog.String("error", err.Error()))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fail(log, err)
+	}
+
+	if report.Invalid > 0 {
+		os.Exit(1)
+	}
+}
+
+func fail(log *slog.Logger, err error) {
+	log.Error("import failed", slog.String("error", err.Error()))
+	os.Exit(1)
+}
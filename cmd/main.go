@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/IskanderSh/hezzl-task/internal/app"
 	"github.com/IskanderSh/hezzl-task/internal/config"
@@ -27,18 +30,38 @@ func main() {
 	log := setupLogger(cfg)
 	log.Info("logger initialized successfully")
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// init app
-	application := app.NewServer(log, cfg)
+	application, err := app.NewServer(ctx, log, cfg)
+	if err != nil {
+		log.Error("couldn't init application", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
 	// start app
-	go func() {
-		if err := application.HTTPServer.ListenAndServe(); err != nil {
-			panic(err)
-		}
-	}()
-	log.Info("application started successfully")
+
+	if err := application.Run(ctx); err != nil {
+		log.Error("application failed", slog.String("error", err.Error()))
+	}
+
+	// a second signal kills the process right away
+	stop()
 
 	// graceful shutdown
+	log.Info("shutting down application")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Application.ShutdownTimeout)
+	defer cancel()
+
+	if err := application.Stop(shutdownCtx); err != nil {
+		log.Error("application stopped with errors", slog.String("error", err.Error()))
+		cancel()
+		os.Exit(1)
+	}
+
+	log.Info("application stopped")
 }
 
 func setupLogger(cfg *config.Config) *slog.Logger {
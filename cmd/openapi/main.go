@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// openapi prints the API specification, or with --check fails when the routes
+// and the specification have drifted apart:
+//
+//	go run ./cmd/openapi --check
+func main() {
+	check := flag.Bool("check", false, "compare the specification with the registered routes")
+	flag.Parse()
+
+	gin.SetMode(gin.ReleaseMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router := handlers.NewGoodHandler(log, nil, nil, nil, nil, config.Idempotency{}, nil, config.Feed{}, nil, nil).InitRoutes()
+
+	doc := handlers.OpenAPI()
+
+	if *check {
+		problems := handlers.CheckOpenAPI(router.Routes(), doc)
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, problem)
+		}
+
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(doc); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
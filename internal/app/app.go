@@ -2,52 +2,163 @@ package app
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"sync"
 
 	"github.com/IskanderSh/hezzl-task/internal/clients"
 	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/gqlserver"
+	"github.com/IskanderSh/hezzl-task/internal/grpcserver"
 	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/lib/retry"
+	"github.com/IskanderSh/hezzl-task/internal/natsserver"
 	"github.com/IskanderSh/hezzl-task/internal/services"
 	redis "github.com/IskanderSh/hezzl-task/internal/storage/cache"
 	"github.com/IskanderSh/hezzl-task/internal/storage/postgres"
+	"github.com/IskanderSh/hezzl-task/migrations"
 
 	//redis "github.com/IskanderSh/hezzl-task/internal/storage/cache"
 	"github.com/IskanderSh/hezzl-task/internal/storage/clickhouse"
+	"google.golang.org/grpc"
 )
 
+// Server owns every connection and background worker of the application.
+// Run serves until its context is done and Stop releases everything in order.
 type Server struct {
-	HTTPServer *http.Server
+	log          *slog.Logger
+	httpServer   *http.Server
+	grpcServer   *grpc.Server
+	grpcListener net.Listener
+	natsServer   *natsserver.Server
+	brokerServer *services.NatsServer
+	brokerClient *clients.NatsClient
+	storage      *postgres.Storage
+	cache        *redis.Cache
+	logStorage   *clickhouse.LogStorage
+
+	// workers run until Stop cancels them
+	workers       []func(ctx context.Context)
+	workersWG     sync.WaitGroup
+	cancelWorkers context.CancelFunc
 }
 
-func NewServer(log *slog.Logger, cfg *config.Config) *Server {
+// NewServer connects to the dependencies, retrying with the startup policy
+// until ctx is done. Optional ones that are unreachable keep connecting in the
+// background, and the application serves without them meanwhile. When a step
+// fails, whatever was already opened is released in reverse order.
+func NewServer(ctx context.Context, log *slog.Logger, cfg *config.Config) (_ *Server, err error) {
+	const op = "app.NewServer"
+
+	var closers []func(ctx context.Context) error
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Application.ShutdownTimeout)
+		defer cancel()
+
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i](ctx); err != nil {
+				log.Warn("couldn't release dependency after failed start", slog.String("error", err.Error()))
+			}
+		}
+	}()
+
+	closeWith := func(fn func() error) func(ctx context.Context) error {
+		return func(context.Context) error { return fn() }
+	}
+
+	policy := retry.Policy{
+		Attempts: cfg.Startup.Attempts,
+		Delay:    cfg.Startup.Delay,
+		MaxDelay: cfg.Startup.MaxDelay,
+	}
+
+	var workers []func(ctx context.Context)
+
 	//Storages
-	storage, err := postgres.NewStorage(log, cfg.Storage)
+	var storage *postgres.Storage
+	err = connect(ctx, log, policy, "storage", func(ctx context.Context) (err error) {
+		storage, err = postgres.NewStorage(log, cfg.Storage)
+		return err
+	})
 	if err != nil {
-		panic(err)
+		return nil, wrapper.Wrap(op, err)
 	}
 	log.Info("successfully create connection to storage")
+	closers = append(closers, closeWith(storage.Close))
 
-	ctx := context.Background()
-	cache, err := redis.NewCache(ctx, log, cfg.Cache)
-	if err != nil {
-		panic(err)
+	cache := redis.NewCache(log, cfg.Cache)
+	closers = append(closers, closeWith(cache.Close))
+
+	if !cfg.Cache.Optional {
+		if err := connect(ctx, log, policy, "cache", cache.Ping); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+		log.Info("successfully create connection to cache")
+	} else if err := cache.Ping(ctx); err != nil {
+		// the breaker opens once calls keep failing and probes until Redis answers
+		log.Warn("cache is unreachable, serving from storage until it connects", slog.String("error", err.Error()))
+	} else {
+		log.Info("successfully create connection to cache")
 	}
-	log.Info("successfully create connection to cache")
 
-	logStorage, err := clickhouse.NewLogStorage(ctx, log, cfg.LogStorage)
+	guardedCache := redis.NewBreakerCache(log, cache, cfg.Cache.Breaker)
+	workers = append(workers, guardedCache.Run)
+
+	expvar.Publish("cache_breaker", expvar.Func(func() any {
+		return guardedCache.BreakerStats()
+	}))
+
+	logStorage, err := clickhouse.NewLogStorage(log, cfg.LogStorage)
 	if err != nil {
-		panic(err)
+		return nil, wrapper.Wrap(op, err)
+	}
+	closers = append(closers, closeWith(logStorage.Close))
+
+	if !cfg.LogStorage.Optional {
+		if err := connect(ctx, log, policy, "log storage", logStorage.Connect); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+		log.Info("successfully create connection to log storage")
+	} else if err := logStorage.Connect(ctx); err != nil {
+		log.Warn("log storage is unreachable, serving without history until it connects", slog.String("error", err.Error()))
+
+		// retried for as long as the application runs
+		background := retry.Policy{Delay: policy.Delay, MaxDelay: policy.MaxDelay}
+		workers = append(workers, func(ctx context.Context) {
+			if err := connect(ctx, log, background, "log storage", logStorage.Connect); err == nil {
+				log.Info("successfully create connection to log storage")
+			}
+		})
+	} else {
+		log.Info("successfully create connection to log storage")
 	}
-	log.Info("successfully create connection to log storage")
 
 	// Clients
-	brokerClient, err := clients.NewNatsClient(log, cfg.MessageBroker, logStorage)
+	var brokerClient *clients.NatsClient
+	err = connect(ctx, log, policy, "message broker", func(ctx context.Context) (err error) {
+		brokerClient, err = clients.NewNatsClient(log, cfg.MessageBroker, logStorage)
+		return err
+	})
 	if err != nil {
-		panic(err)
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	closers = append(closers, brokerClient.Close)
+
+	if brokerClient.Connection().IsConnected() {
+		log.Info("successfully create connection to message broker")
+	} else {
+		log.Warn("message broker is unreachable, logs and events are buffered until it connects")
 	}
-	log.Info("successfully create connection to message broker")
 
 	// Subscribe to subject
 	go func() {
@@ -57,24 +168,250 @@ func NewServer(log *slog.Logger, cfg *config.Config) *Server {
 	}()
 
 	// Services
-	brokerServer, err := services.NewNatsServer(log, cfg.MessageBroker)
+	var brokerServer *services.NatsServer
+	err = connect(ctx, log, policy, "message broker", func(ctx context.Context) (err error) {
+		brokerServer, err = services.NewNatsServer(log, cfg.MessageBroker)
+		return err
+	})
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+	closers = append(closers, brokerServer.Close)
+
+	goodService := services.NewGoodService(log, storage, guardedCache, brokerServer, cfg.Suggest)
+	importService := services.NewImportService(log, storage, guardedCache, brokerServer)
+	projectService := services.NewProjectService(log, storage)
+	historyService := services.NewHistoryService(log, logStorage)
+	feedService := services.NewFeedService(log, cfg.Feed)
+	webhookService := services.NewWebhookService(log, storage, cfg.Webhooks)
+	healthService := services.NewHealthService(log, cfg.Readiness, guardedCache,
+		services.HealthCheck{Name: "postgres", Check: storage.Ping},
+		services.HealthCheck{Name: "migrations", Check: func(ctx context.Context) error {
+			return storage.CheckMigrations(ctx, migrations.FS)
+		}},
+		services.HealthCheck{Name: "redis", Optional: cfg.Cache.Optional, Check: cache.Ping},
+		services.HealthCheck{Name: "nats", Optional: cfg.MessageBroker.Optional, Check: func(ctx context.Context) error {
+			if err := brokerClient.Ping(ctx); err != nil {
+				return err
+			}
+			return brokerServer.Ping(ctx)
+		}},
+		services.HealthCheck{Name: "clickhouse", Optional: cfg.LogStorage.Optional, Check: logStorage.Ping},
+		services.HealthCheck{Name: "clickhouse_migrations", Optional: cfg.LogStorage.Optional, Check: func(ctx context.Context) error {
+			return logStorage.CheckMigrations(ctx, migrations.ClickHouseFS)
+		}},
+	)
+
+	// Feed of the same logs that go to the log storage
+	if err := brokerClient.SubscribeLogs(feedService.Publish); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	// Request-reply over NATS
+	natsServer, err := natsserver.NewServer(log, brokerClient.Connection(), goodService, cfg.MessageBroker)
 	if err != nil {
-		panic(err)
+		return nil, wrapper.Wrap(op, err)
+	}
+	closers = append(closers, closeWith(natsServer.Stop))
+
+	// Workers
+	workers = append(workers, brokerServer.Run)
+
+	if cfg.Webhooks.Enabled {
+		// the queue group delivers every event from one instance only
+		if err := brokerClient.QueueSubscribeLogs(cfg.Webhooks.QueueGroup, webhookService.Publish); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+		workers = append(workers, webhookService.Run)
 	}
 
-	goodService := services.NewGoodService(log, storage, cache, brokerServer)
+	if cfg.Retention.Enabled {
+		retentionWorker := services.NewRetentionWorker(log, cfg.Retention, goodService, guardedCache)
+		workers = append(workers, retentionWorker.Run)
+	}
 
 	// Handlers
-	handler := handlers.NewGoodHandler(log, goodService)
+	handler := handlers.NewGoodHandler(log, goodService, importService, projectService, guardedCache, cfg.Idempotency, feedService, cfg.Feed, webhookService, healthService)
 
 	// Router
 	router := handler.InitRoutes()
 
+	// GraphQL
+	graphQLServer := gqlserver.NewServer(log, goodService, projectService, historyService)
+	router.POST("/graphql", graphQLServer.Handle)
+
 	// HTTPServer
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Application.Port),
 		Handler: router,
 	}
+	// Shutdown doesn't interrupt streams, they would hold it until the deadline
+	httpServer.RegisterOnShutdown(feedService.Close)
+
+	// GRPCServer
+	grpcServer := grpcserver.NewServer(log, goodService, projectService)
+
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPC.Port))
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return &Server{
+		log:          log,
+		httpServer:   httpServer,
+		grpcServer:   grpcServer,
+		grpcListener: grpcListener,
+		natsServer:   natsServer,
+		brokerServer: brokerServer,
+		brokerClient: brokerClient,
+		storage:      storage,
+		cache:        cache,
+		logStorage:   logStorage,
+		workers:      workers,
+	}, nil
+}
+
+// Run starts the workers and the servers and blocks until ctx is done or a
+// server fails. Either way Stop must be called afterwards.
+func (s *Server) Run(ctx context.Context) error {
+	const op = "app.Run"
+
+	workersCtx, cancel := context.WithCancel(context.Background())
+	s.cancelWorkers = cancel
+
+	for _, worker := range s.workers {
+		s.workersWG.Add(1)
+		go func(worker func(ctx context.Context)) {
+			defer s.workersWG.Done()
+			worker(workersCtx)
+		}(worker)
+	}
+
+	errs := make(chan error, 2)
+
+	go func() {
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- fmt.Errorf("http server: %w", err)
+		}
+	}()
+
+	go func() {
+		if err := s.grpcServer.Serve(s.grpcListener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			errs <- fmt.Errorf("grpc server: %w", err)
+		}
+	}()
+
+	s.log.Info("application started successfully")
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return fmt.Errorf("%s: %w", op, err)
+	}
+}
+
+// Stop stops taking requests, waits for the workers, hands the buffered logs
+// over to NATS and closes the connections. Every step is attempted even when
+// an earlier one fails; ctx bounds them all.
+func (s *Server) Stop(ctx context.Context) error {
+	const op = "app.Stop"
+
+	log := s.log.With(slog.String("op", op))
+
+	var errs []error
+
+	step := func(name string, fn func() error) {
+		if err := fn(); err != nil {
+			log.Error(fmt.Sprintf("couldn't %s", name), slog.String("error", err.Error()))
+			errs = append(errs, err)
+			return
+		}
+		log.Info(name + ": done")
+	}
+
+	step("shut down http server", func() error {
+		return s.httpServer.Shutdown(ctx)
+	})
+
+	step("stop grpc server", func() error {
+		return s.stopGRPC(ctx)
+	})
+
+	step("stop nats request-reply service", s.natsServer.Stop)
+
+	step("stop workers", func() error {
+		return s.stopWorkers(ctx)
+	})
+
+	step("flush logs to nats", func() error {
+		return s.brokerServer.Close(ctx)
+	})
+
+	step("drain nats subscriptions", func() error {
+		return s.brokerClient.Close(ctx)
+	})
+
+	step("close postgres connection", s.storage.Close)
+	step("close redis connection", s.cache.Close)
+	step("close clickhouse connection", s.logStorage.Close)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %w", op, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// stopGRPC lets the running calls finish, and cuts them off when ctx is done.
+func (s *Server) stopGRPC(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) stopWorkers(ctx context.Context) error {
+	if s.cancelWorkers == nil {
+		return nil
+	}
+	s.cancelWorkers()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.workersWG.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// connect retries fn with the policy and logs every failed attempt.
+func connect(ctx context.Context, log *slog.Logger, policy retry.Policy, name string, fn func(ctx context.Context) error) error {
+	attempt := 0
+
+	return retry.Do(ctx, policy, func(ctx context.Context) error {
+		attempt++
+
+		err := fn(ctx)
+		if err != nil {
+			log.Warn(fmt.Sprintf("couldn't connect to %s", name), slog.Int("attempt", attempt), slog.String("error", err.Error()))
+		}
 
-	return &Server{HTTPServer: httpServer}
+		return err
+	})
 }
@@ -3,6 +3,7 @@ package clients
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -12,11 +13,16 @@ import (
 	"github.com/nats-io/nats.go"
 )
 
+var (
+	ErrNotConnected = errors.New("message broker is not connected")
+)
+
 type NatsClient struct {
 	log        *slog.Logger
 	connection *nats.Conn
 	subject    string
 	provider   LogsProvider
+	closed     <-chan struct{}
 }
 
 type LogsProvider interface {
@@ -26,15 +32,98 @@ type LogsProvider interface {
 func NewNatsClient(log *slog.Logger, cfg config.MessageBroker, provider LogsProvider) (*NatsClient, error) {
 	const op = "clients.nats.NewNatsClient"
 
+	nc, closed, err := ConnectNats(log, cfg)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return &NatsClient{log: log, connection: nc, subject: cfg.Subject, provider: provider, closed: closed}, nil
+}
+
+// ConnectNats connects to the broker; the returned channel is closed together
+// with the connection. An optional broker doesn't have to be reachable: the
+// connection is retried in the background and subscriptions and publishes
+// wait for it.
+func ConnectNats(log *slog.Logger, cfg config.MessageBroker) (*nats.Conn, <-chan struct{}, error) {
+	const op = "clients.nats.ConnectNats"
+
 	connectString := fmt.Sprintf("nats://%s:%d", cfg.Host, cfg.Port)
 	log.Debug(fmt.Sprintf("connection string for nats: %s", connectString))
 
-	nc, err := nats.Connect(connectString)
+	closed := make(chan struct{})
+
+	options := []nats.Option{
+		nats.ClosedHandler(func(*nats.Conn) { close(closed) }),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Warn("disconnected from message broker", slog.String("error", err.Error()))
+			}
+		}),
+		nats.ReconnectHandler(func(*nats.Conn) { log.Info("reconnected to message broker") }),
+	}
+
+	if cfg.Optional {
+		options = append(options,
+			nats.RetryOnFailedConnect(true),
+			nats.MaxReconnects(-1),
+			nats.ConnectHandler(func(*nats.Conn) { log.Info("connected to message broker") }),
+		)
+	}
+
+	nc, err := nats.Connect(connectString, options...)
 	if err != nil {
-		return nil, wrapper.Wrap(op, err)
+		return nil, nil, wrapper.Wrap(op, err)
 	}
 
-	return &NatsClient{log: log, connection: nc, subject: cfg.Subject, provider: provider}, nil
+	return nc, closed, nil
+}
+
+// Close drains the subscriptions: the messages already received are still
+// handled before the connection is closed.
+func (nc *NatsClient) Close(ctx context.Context) error {
+	const op = "clients.nats.Close"
+
+	if err := nc.connection.Drain(); err != nil {
+		// Drain closes a connection that never connected
+		if errors.Is(err, nats.ErrConnectionReconnecting) {
+			nc.log.Warn("message broker is not connected, nothing to drain", slog.String("op", op))
+			return nil
+		}
+		return wrapper.Wrap(op, err)
+	}
+
+	select {
+	case <-nc.closed:
+		return nil
+	case <-ctx.Done():
+		nc.connection.Close()
+		return wrapper.Wrap(op, ctx.Err())
+	}
+}
+
+func (nc *NatsClient) Ping(ctx context.Context) error {
+	const op = "clients.nats.Ping"
+
+	if err := PingNats(ctx, nc.connection); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// PingNats makes a round trip to the server. A connection that is down fails
+// at once instead of waiting for the reconnect.
+func PingNats(ctx context.Context, nc *nats.Conn) error {
+	if !nc.IsConnected() {
+		return ErrNotConnected
+	}
+
+	return nc.FlushWithContext(ctx)
+}
+
+// Connection is shared with the request-reply goods service.
+func (nc *NatsClient) Connection() *nats.Conn {
+	return nc.connection
 }
 
 func (nc *NatsClient) SubscribeSubjects() error {
@@ -48,6 +137,46 @@ func (nc *NatsClient) SubscribeSubjects() error {
 	return nil
 }
 
+// SubscribeLogs passes every batch of logs published to the subject to fn.
+func (nc *NatsClient) SubscribeLogs(fn func(logs []models.GoodLog)) error {
+	const op = "clients.nats.SubscribeLogs"
+
+	_, err := nc.connection.Subscribe(nc.subject, nc.logsHandler(op, fn))
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// QueueSubscribeLogs is SubscribeLogs where each batch goes to only one
+// subscriber of the queue group.
+func (nc *NatsClient) QueueSubscribeLogs(queue string, fn func(logs []models.GoodLog)) error {
+	const op = "clients.nats.QueueSubscribeLogs"
+
+	_, err := nc.connection.QueueSubscribe(nc.subject, queue, nc.logsHandler(op, fn))
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (nc *NatsClient) logsHandler(op string, fn func(logs []models.GoodLog)) nats.MsgHandler {
+	log := nc.log.With(slog.String("op", op))
+
+	return func(m *nats.Msg) {
+		var logs []models.GoodLog
+
+		if err := json.Unmarshal(m.Data, &logs); err != nil {
+			log.Error("couldn't convert data to logs struct")
+			return
+		}
+
+		fn(logs)
+	}
+}
+
 func (nc *NatsClient) ReceiveLog(m *nats.Msg) {
 	const op = "clients.nats.ReceiveLog"
 
@@ -63,6 +192,6 @@ func (nc *NatsClient) ReceiveLog(m *nats.Msg) {
 
 	ctx := context.Background()
 	if err := nc.provider.NewLogs(ctx, &logs); err != nil {
-		log.Error("couldn't save logs in logs storage", err)
+		log.Error("couldn't save logs in logs storage", slog.String("error", err.Error()))
 	}
 }
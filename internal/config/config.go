@@ -12,14 +12,28 @@ type Config struct {
 	Env           string        `yaml:"env"`
 	LogLevel      string        `yaml:"log_level"`
 	Application   Application   `yaml:"application"`
+	GRPC          GRPC          `yaml:"grpc"`
 	Storage       Storage       `yaml:"storage"`
 	Cache         Cache         `yaml:"cache"`
 	MessageBroker MessageBroker `yaml:"broker"`
 	LogStorage    LogStorage    `yaml:"log_storage"`
+	Suggest       Suggest       `yaml:"suggest"`
+	Retention     Retention     `yaml:"retention"`
+	Idempotency   Idempotency   `yaml:"idempotency"`
+	Feed          Feed          `yaml:"feed"`
+	Webhooks      Webhooks      `yaml:"webhooks"`
+	Startup       Startup       `yaml:"startup"`
+	Readiness     Readiness     `yaml:"readiness"`
 }
 
 type Application struct {
 	Port int `yaml:"port"`
+	// ShutdownTimeout bounds the whole shutdown, in-flight requests included.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"15s"`
+}
+
+type GRPC struct {
+	Port int `yaml:"port" env-default:"1112"`
 }
 
 type Storage struct {
@@ -30,20 +44,99 @@ type Storage struct {
 }
 
 type Cache struct {
-	Host string        `yaml:"host"`
-	Port int           `yaml:"port"`
-	TTL  time.Duration `yaml:"ttl"`
+	Host    string        `yaml:"host"`
+	Port    int           `yaml:"port"`
+	TTL     time.Duration `yaml:"ttl"`
+	Breaker CacheBreaker  `yaml:"breaker"`
+	// Optional: reads go to storage and idempotency keys aren't checked while Redis is down.
+	Optional bool `yaml:"optional"`
+}
+
+// CacheBreaker bypasses the cache after Threshold consecutive failures and
+// probes it every ProbeInterval until it answers again.
+type CacheBreaker struct {
+	Threshold     int           `yaml:"threshold" env-default:"5"`
+	Timeout       time.Duration `yaml:"timeout" env-default:"250ms"`
+	ProbeInterval time.Duration `yaml:"probe_interval" env-default:"5s"`
 }
 
 type MessageBroker struct {
 	Port    int    `yaml:"port"`
 	Host    string `yaml:"host"`
-	Subject string `yaml:"subject"'`
+	Subject string `yaml:"subject"`
+	// QueueGroup balances goods.* requests between the service instances.
+	QueueGroup     string        `yaml:"queue_group" env-default:"goods"`
+	RequestTimeout time.Duration `yaml:"request_timeout" env-default:"10s"`
+	// FlushInterval bounds how long a log waits in a partial batch.
+	FlushInterval time.Duration `yaml:"flush_interval" env-default:"250ms"`
+	// Optional: publishes are buffered while the broker is down.
+	Optional bool `yaml:"optional"`
 }
 
 type LogStorage struct {
 	Port int    `yaml:"port"`
 	Host string `yaml:"host"`
+	// Optional: history is unavailable while the log storage is down.
+	Optional bool `yaml:"optional"`
+}
+
+// Startup is the retry policy for connecting to the dependencies at startup.
+// Dependencies marked optional aren't waited for: the application starts
+// without them, keeps connecting in the background and stays ready while
+// they are down.
+type Startup struct {
+	Attempts int           `yaml:"attempts" env-default:"10"`
+	Delay    time.Duration `yaml:"delay" env-default:"500ms"`
+	MaxDelay time.Duration `yaml:"max_delay" env-default:"10s"`
+}
+
+// Readiness bounds every dependency check of the readiness probe.
+type Readiness struct {
+	Timeout time.Duration `yaml:"timeout" env-default:"2s"`
+}
+
+type Suggest struct {
+	Timeout  time.Duration `yaml:"timeout" env-default:"150ms"`
+	MaxLimit int           `yaml:"max_limit" env-default:"20"`
+}
+
+type Retention struct {
+	Enabled   bool          `yaml:"enabled"`
+	Interval  time.Duration `yaml:"interval" env-default:"1h"`
+	Window    time.Duration `yaml:"window" env-default:"720h"`
+	BatchSize int           `yaml:"batch_size" env-default:"500"`
+	LockTTL   time.Duration `yaml:"lock_ttl" env-default:"10m"`
+}
+
+type Feed struct {
+	// BufferSize is how many recent events are kept for Last-Event-ID resumes.
+	BufferSize       int           `yaml:"buffer_size" env-default:"1024"`
+	SubscriberBuffer int           `yaml:"subscriber_buffer" env-default:"64"`
+	Heartbeat        time.Duration `yaml:"heartbeat" env-default:"15s"`
+}
+
+type Webhooks struct {
+	Enabled bool `yaml:"enabled"`
+	// QueueGroup makes every event delivered by a single instance.
+	QueueGroup  string        `yaml:"queue_group" env-default:"webhooks"`
+	Workers     int           `yaml:"workers" env-default:"4"`
+	QueueSize   int           `yaml:"queue_size" env-default:"1000"`
+	Timeout     time.Duration `yaml:"timeout" env-default:"5s"`
+	MaxAttempts int           `yaml:"max_attempts" env-default:"5"`
+	BaseBackoff time.Duration `yaml:"base_backoff" env-default:"1s"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" env-default:"1m"`
+	// DisableAfter is how many deliveries in a row may fail before the
+	// webhook is disabled.
+	DisableAfter int `yaml:"disable_after" env-default:"10"`
+	// AllowPrivate lets webhooks point to loopback, private and link-local
+	// addresses, e.g. in development. Otherwise such URLs are rejected and
+	// deliveries bypass HTTP proxies to check the receiver's address.
+	AllowPrivate bool `yaml:"allow_private"`
+}
+
+type Idempotency struct {
+	TTL     time.Duration `yaml:"ttl" env-default:"24h"`
+	LockTTL time.Duration `yaml:"lock_ttl" env-default:"1m"`
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,108 @@
+package gqlserver
+
+import (
+	"context"
+	"sync"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/dataloader"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+)
+
+type loadersKey struct{}
+
+// loaders live for one request, so nothing is cached between requests.
+type loaders struct {
+	projectProvider ProjectProvider
+	historyProvider HistoryProvider
+
+	projects *dataloader.Loader[int, *models.Project]
+	rules    *dataloader.Loader[int, *models.ProjectRules]
+	goods    *dataloader.Loader[models.GoodKey, *models.Good]
+
+	// pages and history depend on field arguments, so there is a loader per
+	// distinct set of arguments.
+	mu      sync.Mutex
+	pages   map[models.ProjectGoodsFilter]*dataloader.Loader[int, *goodsPage]
+	history map[int]*dataloader.Loader[models.GoodKey, []models.GoodLog]
+}
+
+// goodsPage keeps the keys of all goods fetched in the same batch, so that
+// nested fields of these goods are batched across projects too.
+type goodsPage struct {
+	*models.GoodsPage
+	siblings []models.GoodKey
+}
+
+func newLoaders(projectProvider ProjectProvider, historyProvider HistoryProvider) *loaders {
+	return &loaders{
+		projectProvider: projectProvider,
+		historyProvider: historyProvider,
+		projects:        dataloader.New(projectProvider.GetProjects),
+		rules:           dataloader.New(projectProvider.GetProjectsRules),
+		goods:           dataloader.New(projectProvider.GetGoodsByKeys),
+		pages:           make(map[models.ProjectGoodsFilter]*dataloader.Loader[int, *goodsPage]),
+		history:         make(map[int]*dataloader.Loader[models.GoodKey, []models.GoodLog]),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (l *loaders) pageLoader(filter models.ProjectGoodsFilter) *dataloader.Loader[int, *goodsPage] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	loader, ok := l.pages[filter]
+	if !ok {
+		loader = dataloader.New(func(ctx context.Context, ids []int) (map[int]*goodsPage, error) {
+			pages, err := l.projectProvider.GetProjectsGoods(ctx, ids, &filter)
+			if err != nil {
+				return nil, err
+			}
+
+			var siblings []models.GoodKey
+			for _, page := range pages {
+				siblings = append(siblings, goodKeys(page.Goods)...)
+			}
+
+			res := make(map[int]*goodsPage, len(pages))
+			for id, page := range pages {
+				res[id] = &goodsPage{GoodsPage: page, siblings: siblings}
+			}
+
+			return res, nil
+		})
+		l.pages[filter] = loader
+	}
+
+	return loader
+}
+
+func (l *loaders) historyLoader(limit int) *dataloader.Loader[models.GoodKey, []models.GoodLog] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	loader, ok := l.history[limit]
+	if !ok {
+		loader = dataloader.New(func(ctx context.Context, keys []models.GoodKey) (map[models.GoodKey][]models.GoodLog, error) {
+			return l.historyProvider.GetGoodsHistory(ctx, keys, limit)
+		})
+		l.history[limit] = loader
+	}
+
+	return loader
+}
+
+func goodKeys(goods []models.Good) []models.GoodKey {
+	keys := make([]models.GoodKey, len(goods))
+	for i, good := range goods {
+		keys[i] = models.GoodKey{ID: good.ID, ProjectID: good.ProjectID}
+	}
+
+	return keys
+}
@@ -0,0 +1,520 @@
+package gqlserver
+
+import (
+	"context"
+
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/IskanderSh/hezzl-task/internal/services"
+	"github.com/graph-gophers/graphql-go"
+)
+
+const maxFirst = 100
+
+// resolver is the root of both queries and mutations.
+type resolver struct {
+	serviceProvider handlers.ServiceProvider
+}
+
+func (r *resolver) Project(ctx context.Context, args struct{ ID int32 }) (*projectResolver, error) {
+	if err := requireID("id", args.ID); err != nil {
+		return nil, err
+	}
+
+	project, err := loadersFrom(ctx).projects.Load(ctx, int(args.ID))
+	if err != nil || project == nil {
+		return nil, err
+	}
+
+	return &projectResolver{project: project, siblings: []int{project.ID}}, nil
+}
+
+func (r *resolver) Projects(ctx context.Context, args struct {
+	First  int32
+	Offset int32
+}) ([]*projectResolver, error) {
+	limit, offset, err := page(args.First, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := loadersFrom(ctx).projectProvider.ListProjects(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	siblings := make([]int, len(projects))
+	for i, project := range projects {
+		siblings[i] = project.ID
+	}
+
+	res := make([]*projectResolver, len(projects))
+	for i := range projects {
+		res[i] = &projectResolver{project: &projects[i], siblings: siblings}
+	}
+
+	return res, nil
+}
+
+func (r *resolver) Good(ctx context.Context, args struct {
+	ID        int32
+	ProjectID int32
+}) (*goodResolver, error) {
+	if err := requireIDs(args.ID, args.ProjectID); err != nil {
+		return nil, err
+	}
+
+	good, err := loadersFrom(ctx).goods.Load(ctx, models.GoodKey{ID: int(args.ID), ProjectID: int(args.ProjectID)})
+	if err != nil || good == nil {
+		return nil, err
+	}
+
+	return newGoodResolver(good), nil
+}
+
+type createGoodInput struct {
+	Name string
+}
+
+func (r *resolver) CreateGood(ctx context.Context, args struct {
+	ProjectID int32
+	Input     createGoodInput
+}) (*goodResolver, error) {
+	if err := requireID("project_id", args.ProjectID); err != nil {
+		return nil, err
+	}
+
+	input := models.CreateRequest{
+		ProjectID: int(args.ProjectID),
+		Name:      args.Input.Name,
+	}
+	if err := handlers.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := r.serviceProvider.CreateGood(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGoodResolver(output), nil
+}
+
+type updateGoodInput struct {
+	Name             *string
+	Description      *string
+	ClearDescription bool
+	Version          int32
+}
+
+func (r *resolver) UpdateGood(ctx context.Context, args struct {
+	ID        int32
+	ProjectID int32
+	Input     updateGoodInput
+}) (*goodResolver, error) {
+	if err := requireIDs(args.ID, args.ProjectID); err != nil {
+		return nil, err
+	}
+
+	input := models.UpdateRequest{
+		ID:        int(args.ID),
+		ProjectID: int(args.ProjectID),
+		Version:   int(args.Input.Version),
+	}
+	if args.Input.Name != nil {
+		input.Name = models.NewOptionalString(*args.Input.Name)
+	}
+	if args.Input.Description != nil {
+		input.Description = models.NewOptionalString(*args.Input.Description)
+	}
+	if args.Input.ClearDescription {
+		input.Description = models.OptionalString{Set: true}
+	}
+
+	if err := handlers.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := r.serviceProvider.UpdateGood(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGoodResolver(output), nil
+}
+
+func (r *resolver) RemoveGood(ctx context.Context, args struct {
+	ID        int32
+	ProjectID int32
+	Version   int32
+}) (*removedGoodResolver, error) {
+	if err := requireIDs(args.ID, args.ProjectID); err != nil {
+		return nil, err
+	}
+
+	input := models.DeleteRequest{
+		ID:        int(args.ID),
+		ProjectID: int(args.ProjectID),
+		Version:   int(args.Version),
+	}
+	if err := handlers.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := r.serviceProvider.DeleteGood(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &removedGoodResolver{output: output}, nil
+}
+
+func (r *resolver) RestoreGood(ctx context.Context, args struct {
+	ID        int32
+	ProjectID int32
+}) (*goodResolver, error) {
+	if err := requireIDs(args.ID, args.ProjectID); err != nil {
+		return nil, err
+	}
+
+	output, err := r.serviceProvider.RestoreGood(ctx, &models.RestoreRequest{
+		ID:        int(args.ID),
+		ProjectID: int(args.ProjectID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newGoodResolver(output), nil
+}
+
+func (r *resolver) ReprioritizeGood(ctx context.Context, args struct {
+	ID          int32
+	ProjectID   int32
+	NewPriority int32
+	Version     int32
+}) ([]*priorityResolver, error) {
+	if err := requireIDs(args.ID, args.ProjectID); err != nil {
+		return nil, err
+	}
+
+	input := models.ReprioritizeRequest{
+		ID:          int(args.ID),
+		ProjectID:   int(args.ProjectID),
+		NewPriority: int(args.NewPriority),
+		Version:     int(args.Version),
+	}
+	if err := handlers.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := r.serviceProvider.ReprioritizeGood(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*priorityResolver, len(output.Priorities))
+	for i := range output.Priorities {
+		res[i] = &priorityResolver{priority: &output.Priorities[i]}
+	}
+
+	return res, nil
+}
+
+// projectResolver batches nested fields over its siblings, the projects
+// resolved in the same list.
+type projectResolver struct {
+	project  *models.Project
+	siblings []int
+}
+
+func (r *projectResolver) ID() int32 {
+	return int32(r.project.ID)
+}
+
+func (r *projectResolver) Name() string {
+	return r.project.Name
+}
+
+func (r *projectResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.project.CreatedAt}
+}
+
+func (r *projectResolver) Rules(ctx context.Context) (*rulesResolver, error) {
+	loader := loadersFrom(ctx).rules
+	loader.Want(r.siblings...)
+
+	rules, err := loader.Load(ctx, r.project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		return nil, services.ErrProjectNotFound
+	}
+
+	return &rulesResolver{rules: rules}, nil
+}
+
+type goodsFilterInput struct {
+	Name           *string
+	IncludeRemoved bool
+}
+
+func (r *projectResolver) Goods(ctx context.Context, args struct {
+	Filter *goodsFilterInput
+	First  int32
+	Offset int32
+}) (*connectionResolver, error) {
+	limit, offset, err := page(args.First, args.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := models.ProjectGoodsFilter{Limit: limit, Offset: offset}
+	if args.Filter != nil {
+		if args.Filter.Name != nil {
+			filter.Name = *args.Filter.Name
+		}
+		filter.IncludeRemoved = args.Filter.IncludeRemoved
+	}
+
+	loader := loadersFrom(ctx).pageLoader(filter)
+	loader.Want(r.siblings...)
+
+	goods, err := loader.Load(ctx, r.project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if goods == nil {
+		return &connectionResolver{page: &goodsPage{GoodsPage: &models.GoodsPage{}}}, nil
+	}
+
+	return &connectionResolver{page: goods}, nil
+}
+
+type rulesResolver struct {
+	rules *models.ProjectRules
+}
+
+func (r *rulesResolver) UniqueNames() bool {
+	return r.rules.UniqueNames
+}
+
+func (r *rulesResolver) MaxDescriptionLength() int32 {
+	return int32(r.rules.MaxDescriptionLength)
+}
+
+type connectionResolver struct {
+	page *goodsPage
+}
+
+func (r *connectionResolver) TotalCount() int32 {
+	return int32(r.page.Total)
+}
+
+func (r *connectionResolver) Nodes() []*goodResolver {
+	res := make([]*goodResolver, len(r.page.Goods))
+	for i := range r.page.Goods {
+		res[i] = &goodResolver{good: &r.page.Goods[i], siblings: r.page.siblings}
+	}
+
+	return res
+}
+
+// goodResolver batches nested fields over its siblings, the goods fetched in
+// the same batch.
+type goodResolver struct {
+	good     *models.Good
+	siblings []models.GoodKey
+}
+
+func newGoodResolver(good *models.Good) *goodResolver {
+	return &goodResolver{good: good, siblings: goodKeys([]models.Good{*good})}
+}
+
+func (r *goodResolver) ID() int32 {
+	return int32(r.good.ID)
+}
+
+func (r *goodResolver) ProjectID() int32 {
+	return int32(r.good.ProjectID)
+}
+
+func (r *goodResolver) Name() string {
+	return r.good.Name
+}
+
+func (r *goodResolver) Description() string {
+	return r.good.Description
+}
+
+func (r *goodResolver) Priority() int32 {
+	return int32(r.good.Priority)
+}
+
+func (r *goodResolver) Removed() bool {
+	return r.good.Removed
+}
+
+func (r *goodResolver) RemovedAt() *graphql.Time {
+	if r.good.RemovedAt == nil {
+		return nil
+	}
+
+	return &graphql.Time{Time: *r.good.RemovedAt}
+}
+
+func (r *goodResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.good.CreatedAt}
+}
+
+func (r *goodResolver) Version() int32 {
+	return int32(r.good.Version)
+}
+
+func (r *goodResolver) SKU() string {
+	return r.good.SKU
+}
+
+func (r *goodResolver) Project(ctx context.Context) (*projectResolver, error) {
+	siblings := make([]int, 0, len(r.siblings))
+	for _, key := range r.siblings {
+		siblings = append(siblings, key.ProjectID)
+	}
+
+	loader := loadersFrom(ctx).projects
+	loader.Want(siblings...)
+
+	project, err := loader.Load(ctx, r.good.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if project == nil {
+		return nil, services.ErrProjectNotFound
+	}
+
+	return &projectResolver{project: project, siblings: siblings}, nil
+}
+
+func (r *goodResolver) History(ctx context.Context, args struct{ First int32 }) ([]*eventResolver, error) {
+	limit, _, err := page(args.First, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := loadersFrom(ctx).historyLoader(limit)
+	loader.Want(r.siblings...)
+
+	logs, err := loader.Load(ctx, models.GoodKey{ID: r.good.ID, ProjectID: r.good.ProjectID})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*eventResolver, len(logs))
+	for i := range logs {
+		res[i] = &eventResolver{log: &logs[i]}
+	}
+
+	return res, nil
+}
+
+type eventResolver struct {
+	log *models.GoodLog
+}
+
+func (r *eventResolver) Event() string {
+	return r.log.Event
+}
+
+func (r *eventResolver) Name() string {
+	return r.log.Name
+}
+
+func (r *eventResolver) Description() string {
+	return r.log.Description
+}
+
+func (r *eventResolver) Priority() int32 {
+	return int32(r.log.Priority)
+}
+
+func (r *eventResolver) Removed() bool {
+	return r.log.Removed
+}
+
+func (r *eventResolver) EventTime() graphql.Time {
+	return graphql.Time{Time: r.log.EventTime}
+}
+
+type removedGoodResolver struct {
+	output *models.DeleteResponse
+}
+
+func (r *removedGoodResolver) ID() int32 {
+	return int32(r.output.ID)
+}
+
+func (r *removedGoodResolver) ProjectID() int32 {
+	return int32(r.output.ProjectID)
+}
+
+func (r *removedGoodResolver) Removed() bool {
+	return r.output.Removed
+}
+
+func (r *removedGoodResolver) Version() int32 {
+	return int32(r.output.Version)
+}
+
+type priorityResolver struct {
+	priority *models.Priorities
+}
+
+func (r *priorityResolver) ID() int32 {
+	return int32(r.priority.ID)
+}
+
+func (r *priorityResolver) Priority() int32 {
+	return int32(r.priority.Priority)
+}
+
+func (r *priorityResolver) Version() int32 {
+	return int32(r.priority.Version)
+}
+
+// page checks the first/offset arguments, the schema supplies their defaults.
+func page(first, offset int32) (int, int, error) {
+	limit, skip := int(first), int(offset)
+	if limit < 1 {
+		return 0, 0, apperr.ErrInvalidParam.WithField("first", apperr.CodeMin, 1)
+	}
+	if limit > maxFirst {
+		return 0, 0, apperr.ErrInvalidParam.WithField("first", apperr.CodeMax, maxFirst)
+	}
+
+	if skip < 0 {
+		return 0, 0, apperr.ErrInvalidParam.WithField("offset", apperr.CodeMin, 0)
+	}
+
+	return limit, skip, nil
+}
+
+func requireID(field string, id int32) error {
+	if id <= 0 {
+		return apperr.ErrInvalidParam.WithField(field, apperr.CodeRequired)
+	}
+
+	return nil
+}
+
+func requireIDs(id, projectID int32) error {
+	if err := requireID("id", id); err != nil {
+		return err
+	}
+
+	return requireID("project_id", projectID)
+}
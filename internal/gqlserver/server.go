@@ -0,0 +1,129 @@
+package gqlserver
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/lib/i18n"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+const (
+	maxDepth = 8
+
+	acceptLanguageHeader  = "Accept-Language"
+	contentLanguageHeader = "Content-Language"
+)
+
+//go:embed schema.graphql
+var schema string
+
+type Server struct {
+	log             *slog.Logger
+	schema          *graphql.Schema
+	projectProvider ProjectProvider
+	historyProvider HistoryProvider
+}
+
+type ProjectProvider interface {
+	GetProjects(ctx context.Context, ids []int) (map[int]*models.Project, error)
+	ListProjects(ctx context.Context, limit, offset int) ([]models.Project, error)
+	GetProjectsRules(ctx context.Context, ids []int) (map[int]*models.ProjectRules, error)
+	GetProjectsGoods(ctx context.Context, ids []int, filter *models.ProjectGoodsFilter) (map[int]*models.GoodsPage, error)
+	GetGoodsByKeys(ctx context.Context, keys []models.GoodKey) (map[models.GoodKey]*models.Good, error)
+}
+
+type HistoryProvider interface {
+	GetGoodsHistory(ctx context.Context, keys []models.GoodKey, limit int) (map[models.GoodKey][]models.GoodLog, error)
+}
+
+type request struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// NewServer builds the GraphQL schema. Mutations go through the same service
+// as the REST handlers, reads go through the batching loaders.
+func NewServer(
+	log *slog.Logger,
+	goods handlers.ServiceProvider,
+	projects ProjectProvider,
+	history HistoryProvider,
+) *Server {
+	s := &Server{
+		log:             log,
+		projectProvider: projects,
+		historyProvider: history,
+	}
+
+	s.schema = graphql.MustParseSchema(schema, &resolver{serviceProvider: goods},
+		graphql.MaxDepth(maxDepth),
+		graphql.PanicHandler(s),
+	)
+
+	return s
+}
+
+// Handle serves POST /graphql. Resolver errors carry the same codes as the
+// REST envelope in their extensions.
+func (s *Server) Handle(c *gin.Context) {
+	const op = "gqlserver.Handle"
+
+	log := s.log.With(slog.String("op", op), slog.String("request_id", c.GetString(response.RequestIDKey)))
+
+	var input request
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.NewErrorResponse(c, log, apperr.ErrInvalidBody.WithField("query", apperr.CodeRequired))
+		return
+	}
+
+	lang := i18n.Match(c.GetHeader(acceptLanguageHeader))
+	c.Header(contentLanguageHeader, lang)
+
+	ctx := withLoaders(c.Request.Context(), newLoaders(s.projectProvider, s.historyProvider))
+
+	res := s.schema.Exec(ctx, input.Query, input.OperationName, input.Variables)
+	for _, err := range res.Errors {
+		if err.ResolverError != nil {
+			translateError(log, lang, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// MakePanicError reports a panic in a resolver as an internal error.
+func (s *Server) MakePanicError(ctx context.Context, value interface{}) *gqlerrors.QueryError {
+	err := &gqlerrors.QueryError{ResolverError: fmt.Errorf("panic: %v", value)}
+	translateError(s.log.With(slog.String("op", "gqlserver.MakePanicError")), i18n.DefaultLanguage, err)
+
+	return err
+}
+
+func translateError(log *slog.Logger, lang string, err *gqlerrors.QueryError) {
+	appErr := apperr.ErrInternal
+	errors.As(err.ResolverError, &appErr)
+
+	if appErr.Kind == apperr.Internal {
+		log.Error(err.ResolverError.Error())
+	} else {
+		log.Info(err.ResolverError.Error())
+	}
+
+	err.Message = response.Translate(lang, appErr)
+	err.Extensions = map[string]any{"code": appErr.Code}
+	if details := response.TranslateDetails(lang, appErr.Details); details != nil {
+		err.Extensions["details"] = details
+	}
+}
@@ -0,0 +1,331 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	goodsv1 "github.com/IskanderSh/hezzl-task/pkg/api/goods/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultLimit        = 10
+	defaultListOffset   = 1
+	defaultSearchOffset = 0
+)
+
+type goodsServer struct {
+	goodsv1.UnimplementedGoodsServiceServer
+
+	serviceProvider handlers.ServiceProvider
+}
+
+func (s *goodsServer) CreateGood(ctx context.Context, req *goodsv1.CreateGoodRequest) (*goodsv1.Good, error) {
+	if err := requireID("project_id", req.GetProjectId()); err != nil {
+		return nil, err
+	}
+
+	input := models.CreateRequest{
+		ProjectID: int(req.GetProjectId()),
+		Name:      req.GetName(),
+	}
+	if err := handlers.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := s.serviceProvider.CreateGood(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGood(output), nil
+}
+
+func (s *goodsServer) UpdateGood(ctx context.Context, req *goodsv1.UpdateGoodRequest) (*goodsv1.Good, error) {
+	if err := requireIDs(req.GetId(), req.GetProjectId()); err != nil {
+		return nil, err
+	}
+
+	input := models.UpdateRequest{
+		ID:        int(req.GetId()),
+		ProjectID: int(req.GetProjectId()),
+		Version:   int(req.GetVersion()),
+	}
+	if req.Name != nil {
+		input.Name = models.NewOptionalString(req.GetName())
+	}
+	if req.Description != nil {
+		input.Description = models.NewOptionalString(req.GetDescription())
+	}
+	if req.GetClearDescription() {
+		input.Description = models.OptionalString{Set: true}
+	}
+
+	if err := handlers.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := s.serviceProvider.UpdateGood(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGood(output), nil
+}
+
+func (s *goodsServer) DeleteGood(ctx context.Context, req *goodsv1.DeleteGoodRequest) (*goodsv1.DeleteGoodResponse, error) {
+	if err := requireIDs(req.GetId(), req.GetProjectId()); err != nil {
+		return nil, err
+	}
+
+	input := models.DeleteRequest{
+		ID:        int(req.GetId()),
+		ProjectID: int(req.GetProjectId()),
+		Version:   int(req.GetVersion()),
+	}
+	if err := handlers.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := s.serviceProvider.DeleteGood(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &goodsv1.DeleteGoodResponse{
+		Id:        int64(output.ID),
+		ProjectId: int64(output.ProjectID),
+		Removed:   output.Removed,
+		Version:   int64(output.Version),
+	}, nil
+}
+
+func (s *goodsServer) RestoreGood(ctx context.Context, req *goodsv1.RestoreGoodRequest) (*goodsv1.Good, error) {
+	if err := requireIDs(req.GetId(), req.GetProjectId()); err != nil {
+		return nil, err
+	}
+
+	output, err := s.serviceProvider.RestoreGood(ctx, &models.RestoreRequest{
+		ID:        int(req.GetId()),
+		ProjectID: int(req.GetProjectId()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toGood(output), nil
+}
+
+func (s *goodsServer) ReprioritizeGood(ctx context.Context, req *goodsv1.ReprioritizeGoodRequest) (*goodsv1.ReprioritizeGoodResponse, error) {
+	if err := requireIDs(req.GetId(), req.GetProjectId()); err != nil {
+		return nil, err
+	}
+
+	input := models.ReprioritizeRequest{
+		ID:          int(req.GetId()),
+		ProjectID:   int(req.GetProjectId()),
+		NewPriority: int(req.GetNewPriority()),
+		Version:     int(req.GetVersion()),
+	}
+	if err := handlers.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := s.serviceProvider.ReprioritizeGood(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &goodsv1.ReprioritizeGoodResponse{Priorities: make([]*goodsv1.Priority, 0, len(output.Priorities))}
+	for _, value := range output.Priorities {
+		res.Priorities = append(res.Priorities, &goodsv1.Priority{
+			Id:       int64(value.ID),
+			Priority: int64(value.Priority),
+			Version:  int64(value.Version),
+		})
+	}
+
+	return res, nil
+}
+
+func (s *goodsServer) SearchGoods(ctx context.Context, req *goodsv1.SearchGoodsRequest) (*goodsv1.SearchGoodsResponse, error) {
+	if err := requireID("project_id", req.GetProjectId()); err != nil {
+		return nil, err
+	}
+
+	query := strings.TrimSpace(req.GetQuery())
+	if query == "" {
+		return nil, apperr.ErrInvalidParam.WithField("query", apperr.CodeRequired)
+	}
+
+	if req.GetLimit() < 0 || req.GetOffset() < 0 {
+		return nil, apperr.ErrInvalidParam.WithField("limit", apperr.CodeMin, 0)
+	}
+
+	output, err := s.serviceProvider.SearchGoods(ctx, &models.SearchRequest{
+		ProjectID:      int(req.GetProjectId()),
+		Query:          query,
+		Limit:          withDefault(req.GetLimit(), defaultLimit),
+		Offset:         withDefault(req.GetOffset(), defaultSearchOffset),
+		IncludeRemoved: req.GetIncludeRemoved(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &goodsv1.SearchGoodsResponse{
+		Total:   int64(output.Meta.Total),
+		Results: make([]*goodsv1.SearchResult, 0, len(output.Results)),
+	}
+	for _, value := range output.Results {
+		res.Results = append(res.Results, &goodsv1.SearchResult{
+			Good: toGood(&models.Good{
+				ID:          value.ID,
+				ProjectID:   value.ProjectID,
+				Name:        value.Name,
+				Description: value.Description,
+				Priority:    value.Priority,
+				Removed:     value.Removed,
+				RemovedAt:   value.RemovedAt,
+				CreatedAt:   value.CreatedAt,
+				Version:     value.Version,
+				SKU:         value.SKU,
+			}),
+			Rank:                 value.Rank,
+			NameHighlight:        value.NameHighlight,
+			DescriptionHighlight: value.DescriptionHighlight,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *goodsServer) SuggestGoods(ctx context.Context, req *goodsv1.SuggestGoodsRequest) (*goodsv1.SuggestGoodsResponse, error) {
+	if err := requireID("project_id", req.GetProjectId()); err != nil {
+		return nil, err
+	}
+
+	query := strings.TrimSpace(req.GetQuery())
+	if query == "" {
+		return nil, apperr.ErrInvalidParam.WithField("query", apperr.CodeRequired)
+	}
+
+	output, err := s.serviceProvider.SuggestGoods(ctx, &models.SuggestRequest{
+		ProjectID: int(req.GetProjectId()),
+		Query:     query,
+		Limit:     withDefault(req.GetLimit(), defaultLimit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &goodsv1.SuggestGoodsResponse{Suggestions: make([]*goodsv1.Suggestion, 0, len(output.Suggestions))}
+	for _, value := range output.Suggestions {
+		res.Suggestions = append(res.Suggestions, &goodsv1.Suggestion{
+			Id:    int64(value.ID),
+			Name:  value.Name,
+			Score: value.Score,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *goodsServer) ListGoods(req *goodsv1.ListGoodsRequest, stream goodsv1.GoodsService_ListGoodsServer) error {
+	if req.GetLimit() < 0 || req.GetOffset() < 0 {
+		return apperr.ErrInvalidParam.WithField("limit", apperr.CodeMin, 0)
+	}
+
+	output, err := s.serviceProvider.GetGoods(stream.Context(), 0,
+		withDefault(req.GetLimit(), defaultLimit),
+		withDefault(req.GetOffset(), defaultListOffset),
+		req.GetIncludeRemoved(),
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := range output.Goods {
+		if err := stream.Send(toGood(&output.Goods[i])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *goodsServer) ExportGoods(req *goodsv1.ExportGoodsRequest, stream goodsv1.GoodsService_ExportGoodsServer) error {
+	if err := requireID("project_id", req.GetProjectId()); err != nil {
+		return err
+	}
+
+	input := models.ExportRequest{
+		ProjectID:      int(req.GetProjectId()),
+		IncludeRemoved: req.GetIncludeRemoved(),
+		Name:           req.GetName(),
+		CreatedAfter:   fromTimestamp(req.GetCreatedAfter()),
+		CreatedBefore:  fromTimestamp(req.GetCreatedBefore()),
+	}
+
+	return s.serviceProvider.ExportGoods(stream.Context(), &input, func(good *models.Good) error {
+		return stream.Send(toGood(good))
+	})
+}
+
+func requireID(field string, id int64) error {
+	if id <= 0 {
+		return apperr.ErrInvalidParam.WithField(field, apperr.CodeRequired)
+	}
+
+	return nil
+}
+
+func requireIDs(id, projectID int64) error {
+	if err := requireID("id", id); err != nil {
+		return err
+	}
+
+	return requireID("project_id", projectID)
+}
+
+func withDefault(value int64, defaultValue int) int {
+	if value == 0 {
+		return defaultValue
+	}
+
+	return int(value)
+}
+
+func toGood(good *models.Good) *goodsv1.Good {
+	res := &goodsv1.Good{
+		Id:          int64(good.ID),
+		ProjectId:   int64(good.ProjectID),
+		Name:        good.Name,
+		Description: good.Description,
+		Priority:    int64(good.Priority),
+		Removed:     good.Removed,
+		CreatedAt:   timestamppb.New(good.CreatedAt),
+		Version:     int64(good.Version),
+		Sku:         good.SKU,
+	}
+
+	if good.RemovedAt != nil {
+		res.RemovedAt = timestamppb.New(*good.RemovedAt)
+	}
+
+	return res
+}
+
+func fromTimestamp(value *timestamppb.Timestamp) *time.Time {
+	if value == nil {
+		return nil
+	}
+
+	res := value.AsTime()
+
+	return &res
+}
@@ -0,0 +1,58 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	goodsv1 "github.com/IskanderSh/hezzl-task/pkg/api/goods/v1"
+)
+
+type projectsServer struct {
+	goodsv1.UnimplementedProjectsServiceServer
+
+	projectProvider handlers.ProjectProvider
+}
+
+func (s *projectsServer) GetProjectRules(ctx context.Context, req *goodsv1.GetProjectRulesRequest) (*goodsv1.ProjectRules, error) {
+	if err := requireID("project_id", req.GetProjectId()); err != nil {
+		return nil, err
+	}
+
+	output, err := s.projectProvider.GetRules(ctx, int(req.GetProjectId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return toProjectRules(output), nil
+}
+
+func (s *projectsServer) UpdateProjectRules(ctx context.Context, req *goodsv1.ProjectRules) (*goodsv1.ProjectRules, error) {
+	if err := requireID("project_id", req.GetProjectId()); err != nil {
+		return nil, err
+	}
+
+	input := models.ProjectRules{
+		ProjectID:            int(req.GetProjectId()),
+		UniqueNames:          req.GetUniqueNames(),
+		MaxDescriptionLength: int(req.GetMaxDescriptionLength()),
+	}
+	if err := handlers.Validate(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := s.projectProvider.UpdateRules(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProjectRules(output), nil
+}
+
+func toProjectRules(rules *models.ProjectRules) *goodsv1.ProjectRules {
+	return &goodsv1.ProjectRules{
+		ProjectId:            int64(rules.ProjectID),
+		UniqueNames:          rules.UniqueNames,
+		MaxDescriptionLength: int64(rules.MaxDescriptionLength),
+	}
+}
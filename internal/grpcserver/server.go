@@ -0,0 +1,146 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/i18n"
+	goodsv1 "github.com/IskanderSh/hezzl-task/pkg/api/goods/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+const (
+	errorDomain       = "hezzl-task"
+	acceptLanguageKey = "accept-language"
+)
+
+// NewServer serves the goods and projects APIs on top of the same providers
+// as the REST handlers, together with the health and reflection services.
+func NewServer(log *slog.Logger, goods handlers.ServiceProvider, projects handlers.ProjectProvider) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryErrors(log)),
+		grpc.ChainStreamInterceptor(streamErrors(log)),
+	)
+
+	goodsv1.RegisterGoodsServiceServer(server, &goodsServer{serviceProvider: goods})
+	goodsv1.RegisterProjectsServiceServer(server, &projectsServer{projectProvider: projects})
+
+	healthServer := health.NewServer()
+	for name := range server.GetServiceInfo() {
+		healthServer.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+
+	return server
+}
+
+func unaryErrors(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+			if err != nil {
+				err = toStatus(ctx, log.With(slog.String("op", info.FullMethod)), err)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+func streamErrors(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+			if err != nil {
+				err = toStatus(stream.Context(), log.With(slog.String("op", info.FullMethod)), err)
+			}
+		}()
+
+		return handler(srv, stream)
+	}
+}
+
+// toStatus converts service errors the way the REST envelope does: the code
+// goes to the ErrorInfo reason, field errors to BadRequest, and the message
+// is translated using the accept-language metadata.
+func toStatus(ctx context.Context, log *slog.Logger, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	appErr := apperr.ErrInternal
+	errors.As(err, &appErr)
+
+	code := statusCode(appErr.Kind)
+	if code == codes.Internal {
+		log.Error(err.Error())
+	} else {
+		log.Info(err.Error())
+	}
+
+	lang := i18n.DefaultLanguage
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(acceptLanguageKey)) > 0 {
+		lang = i18n.Match(md.Get(acceptLanguageKey)[0])
+	}
+
+	message, ok := i18n.Translate(lang, appErr.Code)
+	if !ok {
+		message = appErr.Message
+	}
+
+	st := status.New(code, message)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: appErr.Code, Domain: errorDomain}}
+	if len(appErr.Details) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(appErr.Details))
+		for _, detail := range appErr.Details {
+			description, _ := i18n.Translate(lang, detail.Code, detail.Params...)
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       detail.Field,
+				Description: description,
+			})
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
+
+func statusCode(kind apperr.Kind) codes.Code {
+	switch kind {
+	case apperr.Validation, apperr.UnsupportedMediaType, apperr.Unprocessable:
+		return codes.InvalidArgument
+	case apperr.NotFound:
+		return codes.NotFound
+	case apperr.Conflict:
+		return codes.Aborted
+	case apperr.PreconditionFailed:
+		return codes.FailedPrecondition
+	case apperr.Unavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
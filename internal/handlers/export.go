@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	nameCtx          = "name"
+	createdAfterCtx  = "created_after"
+	createdBeforeCtx = "created_before"
+
+	exportFlushEvery = 500
+	exportSheet      = "Goods"
+
+	contentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	// formulaPrefixes make a spreadsheet treat a cell as a formula.
+	formulaPrefixes = "=+-@\t\r"
+)
+
+var exportColumns = []any{"id", "name", "description", "priority", "removed", "removed_at", "created_at", "version", "sku"}
+
+type exportRow struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Priority    int        `json:"priority"`
+	Removed     bool       `json:"removed"`
+	RemovedAt   *time.Time `json:"removed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Version     int        `json:"version"`
+	SKU         string     `json:"sku"`
+}
+
+// goodsEncoder writes goods to the response one at a time.
+type goodsEncoder interface {
+	Encode(good *models.Good) error
+	Close() error
+}
+
+// ExportGoods streams the project's goods as csv, ndjson or xlsx. Rows go out as
+// they are read from storage; once the first row is written the status can't
+// change, so a late failure cuts the stream short instead of sending an error body.
+func (h *GoodHandler) ExportGoods(c *gin.Context) {
+	const op = "handlers.ExportGoods"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	includeRemoved, err := getQueryBool(c, includeRemovedCtx, false)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	createdAfter, err := getQueryTime(c, createdAfterCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	createdBefore, err := getQueryTime(c, createdBeforeCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	input := models.ExportRequest{
+		ProjectID:      projectID,
+		Format:         c.DefaultQuery(formatCtx, models.ExportFormatCSV),
+		IncludeRemoved: includeRemoved,
+		Name:           c.Query(nameCtx),
+		CreatedAfter:   createdAfter,
+		CreatedBefore:  createdBefore,
+	}
+
+	contentType, newEncoder, err := exportEncoder(input.Format)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	filename := fmt.Sprintf("project-%d-goods-%s.%s", projectID, time.Now().Format("20060102-150405"), input.Format)
+
+	var encoder goodsEncoder
+	start := func() error {
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Status(http.StatusOK)
+
+		encoder, err = newEncoder(c.Writer)
+		return err
+	}
+
+	written := 0
+	err = h.serviceProvider.ExportGoods(c, &input, func(good *models.Good) error {
+		if encoder == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := encoder.Encode(good); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+
+		return nil
+	})
+	if err != nil {
+		if encoder == nil {
+			response.NewErrorResponse(c, log, err)
+			return
+		}
+
+		log.Error("export interrupted", slog.Int("written", written), slog.String("error", err.Error()))
+		c.Abort()
+		return
+	}
+
+	if encoder == nil {
+		if err := start(); err != nil {
+			log.Error("couldn't start export", slog.String("error", err.Error()))
+			return
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		log.Error("couldn't finish export", slog.String("error", err.Error()))
+	}
+}
+
+func exportEncoder(format string) (string, func(w io.Writer) (goodsEncoder, error), error) {
+	switch format {
+	case models.ExportFormatCSV:
+		return contentTypeCSV, newCSVEncoder, nil
+	case models.ExportFormatNDJSON:
+		return contentTypeNDJSON, newNDJSONEncoder, nil
+	case models.ExportFormatXLSX:
+		return contentTypeXLSX, newXLSXEncoder, nil
+	default:
+		return "", nil, errInvalidParam.WithField(formatCtx, apperr.CodeUnknownValue, format)
+	}
+}
+
+func getQueryTime(c *gin.Context, param string) (*time.Time, error) {
+	value, ok := c.GetQuery(param)
+	if !ok {
+		return nil, nil
+	}
+
+	res, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errInvalidParam.WithField(param, apperr.CodeTimestamp)
+	}
+
+	return &res, nil
+}
+
+func makeExportRow(good *models.Good) *exportRow {
+	return &exportRow{
+		ID:          good.ID,
+		Name:        good.Name,
+		Description: good.Description,
+		Priority:    good.Priority,
+		Removed:     good.Removed,
+		RemovedAt:   good.RemovedAt,
+		CreatedAt:   good.CreatedAt,
+		Version:     good.Version,
+		SKU:         good.SKU,
+	}
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) (goodsEncoder, error) {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(exportColumns))
+	for i, column := range exportColumns {
+		header[i] = column.(string)
+	}
+
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &csvEncoder{writer: writer}, nil
+}
+
+func (e *csvEncoder) Encode(good *models.Good) error {
+	removedAt := ""
+	if good.RemovedAt != nil {
+		removedAt = good.RemovedAt.Format(time.RFC3339)
+	}
+
+	return e.writer.Write([]string{
+		strconv.Itoa(good.ID),
+		escapeFormula(good.Name),
+		escapeFormula(good.Description),
+		strconv.Itoa(good.Priority),
+		strconv.FormatBool(good.Removed),
+		removedAt,
+		good.CreatedAt.Format(time.RFC3339),
+		strconv.Itoa(good.Version),
+		escapeFormula(good.SKU),
+	})
+}
+
+// escapeFormula prefixes text that a spreadsheet would evaluate with a quote,
+// so the export opens with the value as typed instead of a live formula.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+func (e *csvEncoder) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) (goodsEncoder, error) {
+	return &ndjsonEncoder{encoder: json.NewEncoder(w)}, nil
+}
+
+func (e *ndjsonEncoder) Encode(good *models.Good) error {
+	return e.encoder.Encode(makeExportRow(good))
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// xlsxEncoder uses the excelize stream writer, which spills rows to a temporary
+// file instead of memory. The workbook is only sent on Close, because the
+// xlsx container can't be produced incrementally.
+type xlsxEncoder struct {
+	writer io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXEncoder(w io.Writer) (goodsEncoder, error) {
+	file := excelize.NewFile()
+
+	if err := file.SetSheetName(file.GetSheetName(0), exportSheet); err != nil {
+		return nil, err
+	}
+
+	stream, err := file.NewStreamWriter(exportSheet)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.SetRow("A1", exportColumns); err != nil {
+		return nil, err
+	}
+
+	return &xlsxEncoder{writer: w, file: file, stream: stream, row: 1}, nil
+}
+
+func (e *xlsxEncoder) Encode(good *models.Good) error {
+	e.row++
+
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+
+	var removedAt any
+	if good.RemovedAt != nil {
+		removedAt = *good.RemovedAt
+	}
+
+	// Plain strings are stored as inline string cells, never as formulas.
+	return e.stream.SetRow(cell, []any{
+		good.ID,
+		good.Name,
+		good.Description,
+		good.Priority,
+		good.Removed,
+		removedAt,
+		good.CreatedAt,
+		good.Version,
+		good.SKU,
+	})
+}
+
+func (e *xlsxEncoder) Close() error {
+	defer e.file.Close()
+
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+
+	return e.file.Write(e.writer)
+}
@@ -0,0 +1,100 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"net/http"
+	"testing"
+
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/xuri/excelize/v2"
+)
+
+func (s *fakeService) ExportGoods(_ context.Context, req *models.ExportRequest, fn func(good *models.Good) error) error {
+	s.projectID = req.ProjectID
+
+	for i := range s.exported {
+		if err := fn(&s.exported[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var formulaGoods = []models.Good{
+	{ID: 1, Name: `=HYPERLINK("http://evil.example","x")`, Description: "+cmd|' /C calc'!A0", SKU: "-1"},
+	{ID: 2, Name: "@SUM(A1:A2)", Description: "\tindented", SKU: "\rreturn"},
+	{ID: 3, Name: "plain", Description: "a = b", SKU: "SKU-1"},
+}
+
+func TestExportCSVEscapesFormulas(t *testing.T) {
+	router, service := newRouter(t)
+	service.exported = formulaGoods
+
+	w := serve(router, http.MethodGet, "/v1/projects/7/goods/export?format=csv", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+
+	want := [][3]string{
+		{`'=HYPERLINK("http://evil.example","x")`, "'+cmd|' /C calc'!A0", "'-1"},
+		{"'@SUM(A1:A2)", "'\tindented", "'\rreturn"},
+		{"plain", "a = b", "SKU-1"},
+	}
+
+	if len(rows) != len(want)+1 {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want)+1)
+	}
+
+	for i, row := range rows[1:] {
+		got := [3]string{row[1], row[2], row[8]}
+		if got != want[i] {
+			t.Errorf("row %d = %q, want %q", i+1, got, want[i])
+		}
+	}
+}
+
+func TestExportXLSXWritesStrings(t *testing.T) {
+	router, service := newRouter(t)
+	service.exported = formulaGoods
+
+	w := serve(router, http.MethodGet, "/v1/projects/7/goods/export?format=xlsx", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	file, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("open xlsx: %v", err)
+	}
+	defer file.Close()
+
+	for i, good := range formulaGoods {
+		for col, want := range map[string]string{"B": good.Name, "C": good.Description, "I": good.SKU} {
+			cell := col + string(rune('2'+i))
+
+			formula, err := file.GetCellFormula("Goods", cell)
+			if err != nil {
+				t.Fatalf("formula %s: %v", cell, err)
+			}
+			if formula != "" {
+				t.Errorf("%s has formula %q", cell, formula)
+			}
+
+			value, err := file.GetCellValue("Goods", cell)
+			if err != nil {
+				t.Fatalf("value %s: %v", cell, err)
+			}
+			if value != want {
+				t.Errorf("%s = %q, want %q", cell, value, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+	lastEventIDCtx    = "last_event_id"
+
+	contentTypeEventStream = "text/event-stream"
+
+	// feedResetEvent asks the client to reload the goods, because the events
+	// since its last event id are lost.
+	feedResetEvent = "reset"
+
+	feedRetry            = 3 * time.Second
+	feedWriteTimeout     = 10 * time.Second
+	feedReadLimit        = 512
+	defaultFeedHeartbeat = 15 * time.Second
+)
+
+type FeedProvider interface {
+	Subscribe(projectID int, lastEventID string) *models.FeedSubscription
+	Unsubscribe(sub *models.FeedSubscription)
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// StreamGoods pushes the project's good events as Server-Sent Events. The
+// event id can be sent back in Last-Event-ID (or ?last_event_id) to resume.
+func (h *GoodHandler) StreamGoods(c *gin.Context) {
+	const op = "handlers.StreamGoods"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	sub := h.feedProvider.Subscribe(projectID, lastEventID(c))
+	defer h.feedProvider.Unsubscribe(sub)
+
+	c.Header("Content-Type", contentTypeEventStream)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", feedRetry.Milliseconds()); err != nil {
+		return
+	}
+
+	if sub.Reset {
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: {}\n\n", feedResetEvent); err != nil {
+			return
+		}
+	}
+
+	for i := range sub.Replay {
+		if err := writeServerEvent(w, &sub.Replay[i]); err != nil {
+			return
+		}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(h.feedCfg.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if err := writeServerEvent(w, &event); err != nil {
+				log.Debug("feed client is gone", slog.String("error", err.Error()))
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		w.Flush()
+	}
+}
+
+// StreamGoodsWS is the WebSocket variant of StreamGoods: every event is sent
+// as a JSON text message. Browsers can't set headers here, so the last event
+// id comes from ?last_event_id.
+func (h *GoodHandler) StreamGoodsWS(c *gin.Context) {
+	const op = "handlers.StreamGoodsWS"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	// Upgrade replies with an error status itself when the handshake fails
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Info("websocket upgrade failed", slog.String("error", err.Error()))
+		return
+	}
+	defer conn.Close()
+
+	sub := h.feedProvider.Subscribe(projectID, lastEventID(c))
+	defer h.feedProvider.Unsubscribe(sub)
+
+	// the client doesn't send anything, reading only notices when it leaves
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		conn.SetReadLimit(feedReadLimit)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(value any) error {
+		if err := conn.SetWriteDeadline(time.Now().Add(feedWriteTimeout)); err != nil {
+			return err
+		}
+		return conn.WriteJSON(value)
+	}
+
+	if sub.Reset {
+		if err := write(gin.H{"event": feedResetEvent}); err != nil {
+			return
+		}
+	}
+
+	for _, event := range sub.Replay {
+		if err := write(event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.feedCfg.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "stream interrupted, reconnect to resume"),
+					time.Now().Add(feedWriteTimeout))
+				return
+			}
+			if err := write(event); err != nil {
+				log.Debug("feed client is gone", slog.String("error", err.Error()))
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(feedWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeServerEvent(w io.Writer, event *models.FeedEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Event, data)
+
+	return err
+}
+
+func lastEventID(c *gin.Context) string {
+	if id := c.GetHeader(lastEventIDHeader); id != "" {
+		return id
+	}
+
+	return c.Query(lastEventIDCtx)
+}
@@ -2,60 +2,198 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
 	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/lib/openapi"
 	"github.com/IskanderSh/hezzl-task/internal/models"
-	"github.com/IskanderSh/hezzl-task/internal/services"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 type GoodHandler struct {
-	log             *slog.Logger
-	serviceProvider ServiceProvider
+	log                 *slog.Logger
+	serviceProvider     ServiceProvider
+	importProvider      ImportProvider
+	projectProvider     ProjectProvider
+	idempotencyProvider IdempotencyProvider
+	idempotencyCfg      config.Idempotency
+	feedProvider        FeedProvider
+	feedCfg             config.Feed
+	webhookProvider     WebhookProvider
+	healthProvider      HealthProvider
+	openAPI             *openapi.Document
 }
 
 type ServiceProvider interface {
 	CreateGood(ctx context.Context, req *models.CreateRequest) (*models.Good, error)
 	UpdateGood(ctx context.Context, req *models.UpdateRequest) (*models.Good, error)
 	DeleteGood(ctx context.Context, req *models.DeleteRequest) (*models.DeleteResponse, error)
-	GetGoods(ctx context.Context, limit, offset int) (*models.ListGoodsResponse, error)
+	RestoreGood(ctx context.Context, req *models.RestoreRequest) (*models.Good, error)
+	PurgeGoods(ctx context.Context, req *models.PurgeRequest) (*models.PurgeResponse, error)
+	GetGood(ctx context.Context, projectID, id int) (*models.Good, error)
+	GetGoods(ctx context.Context, projectID, limit, offset int, includeRemoved bool) (*models.ListGoodsResponse, error)
 	ReprioritizeGood(ctx context.Context, req *models.ReprioritizeRequest) (*models.ReprioritizeResponse, error)
+	SearchGoods(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error)
+	SuggestGoods(ctx context.Context, req *models.SuggestRequest) (*models.SuggestResponse, error)
+	BatchGoods(ctx context.Context, req *models.BatchRequest) (*models.BatchResponse, error)
+	ExportGoods(ctx context.Context, req *models.ExportRequest, fn func(good *models.Good) error) error
+	GetChanges(ctx context.Context, req *models.ChangesRequest) (*models.ChangesResponse, error)
 }
 
-func NewGoodHandler(log *slog.Logger, provider ServiceProvider) *GoodHandler {
-	return &GoodHandler{log: log, serviceProvider: provider}
+type ImportProvider interface {
+	ImportGoods(ctx context.Context, req *models.ImportRequest, r io.Reader) (*models.ImportReport, error)
 }
 
+func NewGoodHandler(
+	log *slog.Logger,
+	provider ServiceProvider,
+	importProvider ImportProvider,
+	projectProvider ProjectProvider,
+	idempotencyProvider IdempotencyProvider,
+	idempotencyCfg config.Idempotency,
+	feedProvider FeedProvider,
+	feedCfg config.Feed,
+	webhookProvider WebhookProvider,
+	healthProvider HealthProvider,
+) *GoodHandler {
+	// A zero heartbeat would make the stream tickers panic.
+	if feedCfg.Heartbeat <= 0 {
+		feedCfg.Heartbeat = defaultFeedHeartbeat
+	}
+
+	return &GoodHandler{
+		log:                 log,
+		serviceProvider:     provider,
+		importProvider:      importProvider,
+		projectProvider:     projectProvider,
+		idempotencyProvider: idempotencyProvider,
+		idempotencyCfg:      idempotencyCfg,
+		feedProvider:        feedProvider,
+		feedCfg:             feedCfg,
+		webhookProvider:     webhookProvider,
+		healthProvider:      healthProvider,
+	}
+}
+
+// InitRoutes registers the /v1 REST resources and, next to them, the legacy
+// routes that take ids from the query string (e.g. /good/update?id=1&projectId=1).
 func (h *GoodHandler) InitRoutes() *gin.Engine {
 	r := gin.New()
+	r.Use(RequestID())
+
+	r.GET(openAPIPath, h.GetOpenAPI)
+	r.GET(docsPath, h.GetDocs)
+	r.GET(livenessPath, h.Liveness)
+	r.GET(readinessPath, h.Readiness)
+	r.GET(metricsPath, h.Metrics)
+
+	idempotency := Idempotency(h.log, h.idempotencyProvider, h.idempotencyCfg)
+
+	v1 := r.Group("/v1")
+	{
+		project := v1.Group("/projects/:projectId")
+		{
+			project.GET("/rules", h.GetProjectRules)
+			project.PUT("/rules", h.UpdateProjectRules)
+
+			goods := project.Group("/goods")
+			{
+				goods.GET("", h.ListGoods)
+				goods.POST("", idempotency, h.CreateGood)
+				goods.GET("/search", h.SearchGoods)
+				goods.GET("/suggest", h.SuggestGoods)
+				goods.GET("/export", h.ExportGoods)
+				goods.GET("/changes", h.GetChanges)
+				goods.GET("/stream", h.StreamGoods)
+				goods.GET("/ws", h.StreamGoodsWS)
+				goods.POST("/import", h.ImportGoods)
+				goods.POST("/batch", idempotency, h.BatchGoods)
+				goods.DELETE("/removed", h.PurgeGoods)
+
+				goods.GET("/:id", h.GetGood)
+				goods.PATCH("/:id", idempotency, h.UpdateGood)
+				goods.DELETE("/:id", idempotency, h.DeleteGood)
+				goods.PATCH("/:id/priority", idempotency, h.ReprioritizeGood)
+				goods.POST("/:id/restore", h.RestoreGood)
+			}
+
+			webhooks := project.Group("/webhooks")
+			{
+				webhooks.GET("", h.ListWebhooks)
+				webhooks.POST("", h.CreateWebhook)
+				webhooks.GET("/:id", h.GetWebhook)
+				webhooks.PUT("/:id", h.UpdateWebhook)
+				webhooks.DELETE("/:id", h.DeleteWebhook)
+				webhooks.GET("/:id/deliveries", h.ListWebhookDeliveries)
+			}
+		}
+	}
 
 	good := r.Group("/good")
 	{
-		good.POST("/create/:projectId", h.CreateGood)
-		good.PATCH("/update/:id&:projectId", h.UpdateGood)
-		good.DELETE("/delete/:id&:projectId", h.DeleteGood)
-		good.PATCH("/reprioritize:id&:projectId", h.ReprioritizeGood)
+		good.POST("/create", idempotency, h.CreateGood)
+		good.POST("/create/:projectId", idempotency, h.CreateGood)
+		good.PATCH("/update", idempotency, h.UpdateGood)
+		good.DELETE("/delete", idempotency, h.DeleteGood)
+		good.PATCH("/reprioritize", idempotency, h.ReprioritizeGood)
+		good.POST("/restore", h.RestoreGood)
+		good.DELETE("/purge", h.PurgeGoods)
 	}
 
-	r.GET("/goods/list/:limit&:offset", h.ListGoods)
+	r.GET("/goods/list", h.ListGoods)
+
+	project := r.Group("/project/:projectId")
+	{
+		project.GET("/goods/search", h.SearchGoods)
+		project.GET("/goods/suggest", h.SuggestGoods)
+		project.POST("/goods/batch", idempotency, h.BatchGoods)
+		project.POST("/goods/import", h.ImportGoods)
+		project.GET("/goods/export", h.ExportGoods)
+		project.GET("/goods/changes", h.GetChanges)
+		project.GET("/goods/stream", h.StreamGoods)
+		project.GET("/goods/ws", h.StreamGoodsWS)
+		project.GET("/rules", h.GetProjectRules)
+		project.PUT("/rules", h.UpdateProjectRules)
+	}
+
+	h.openAPI = OpenAPI()
+	for _, problem := range CheckOpenAPI(r.Routes(), h.openAPI) {
+		h.log.Warn(fmt.Sprintf("openapi: %s", problem))
+	}
 
 	return r
 }
 
 const (
-	defaultLimit  = 10
-	defaultOffset = 1
-
-	projectCtx = "projectId"
-	idCtx      = "id"
-	limitCtx   = "limit"
-	offsetCtx  = "offset"
+	defaultLimit        = 10
+	defaultOffset       = 1
+	defaultSearchOffset = 0
+	defaultChangesLimit = 100
+
+	projectCtx        = "projectId"
+	idCtx             = "id"
+	limitCtx          = "limit"
+	offsetCtx         = "offset"
+	queryCtx          = "q"
+	includeRemovedCtx = "include_removed"
+	sinceCtx          = "since"
+
+	ifMatchHeader = "If-Match"
+	eTagHeader    = "ETag"
+)
 
-	goodNotFoundMessage = "errors.good.NotFound"
+var (
+	errInvalidParam         = apperr.ErrInvalidParam
+	errInvalidBody          = apperr.ErrInvalidBody
+	errUnsupportedMediaType = apperr.New(apperr.UnsupportedMediaType, "errors.request.UnsupportedMediaType", "unsupported content type")
 )
 
 func (h *GoodHandler) CreateGood(c *gin.Context) {
@@ -65,15 +203,17 @@ func (h *GoodHandler) CreateGood(c *gin.Context) {
 
 	projectID, err := getID(c, projectCtx)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
 	log.Debug(fmt.Sprintf("successfully get project id: %d", projectID))
 
 	var input models.CreateRequest
 
-	if err := c.BindJSON(&input); err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, "invalid input body")
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.NewErrorResponse(c, log, validationError(err))
+		return
 	}
 
 	log.Debug(fmt.Sprintf("successfully bind input with name: %s", input.Name))
@@ -82,9 +222,11 @@ func (h *GoodHandler) CreateGood(c *gin.Context) {
 
 	output, err := h.serviceProvider.CreateGood(c, &input)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusInternalServerError, "internal error")
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
+	setETag(c, output.Version)
 	c.JSON(http.StatusOK, output)
 }
 
@@ -95,30 +237,37 @@ func (h *GoodHandler) UpdateGood(c *gin.Context) {
 
 	id, err := getID(c, idCtx)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
 	projectId, err := getID(c, projectCtx)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
 	var input models.UpdateRequest
-	if err := c.BindJSON(&input); err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, "invalid input body")
+	if err := bindUpdateRequest(c, &input); err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
 	input.ID = id
 	input.ProjectID = projectId
 
+	if err := applyIfMatch(c, &input.Version); err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
 	output, err := h.serviceProvider.UpdateGood(c, &input)
 	if err != nil {
-		if errors.Is(err, services.ErrGoodNotFound) {
-			response.NewErrorResponse(c, log, http.StatusNotFound, goodNotFoundMessage)
-		}
-		response.NewErrorResponse(c, log, http.StatusInternalServerError, "internal error")
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
+	setETag(c, output.Version)
 	c.JSON(http.StatusOK, output)
 }
 
@@ -129,54 +278,162 @@ func (h *GoodHandler) DeleteGood(c *gin.Context) {
 
 	id, err := getID(c, idCtx)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
 	projectId, err := getID(c, projectCtx)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
 	var input models.DeleteRequest
-	if err := c.BindJSON(&input); err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, "invalid input body")
+	if err := bindOptionalJSON(c, &input); err != nil {
+		response.NewErrorResponse(c, log, validationError(err))
+		return
 	}
 
 	input.ID = id
 	input.ProjectID = projectId
 
+	if err := applyIfMatch(c, &input.Version); err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
 	output, err := h.serviceProvider.DeleteGood(c, &input)
 	if err != nil {
-		if errors.Is(err, services.ErrGoodNotFound) {
-			response.NewErrorResponse(c, log, http.StatusNotFound, goodNotFoundMessage)
-		}
-		response.NewErrorResponse(c, log, http.StatusInternalServerError, "internal error")
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	setETag(c, output.Version)
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *GoodHandler) GetGood(c *gin.Context) {
+	const op = "handlers.GetGood"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := getID(c, idCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
+	output, err := h.serviceProvider.GetGood(c, projectID, id)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	setETag(c, output.Version)
 	c.JSON(http.StatusOK, output)
 }
 
+// ListGoods lists the goods of the project in the path or, on the legacy
+// route, of the optional projectId query parameter, or of every project.
 func (h *GoodHandler) ListGoods(c *gin.Context) {
 	const op = "handlers.ListGoods"
 
 	log := h.log.With(slog.String("op", op))
 
-	limit := defaultLimit
-	offset := defaultOffset
+	projectID := 0
+	if c.Param(projectCtx) != "" || c.Query(projectCtx) != "" {
+		var err error
+		if projectID, err = getID(c, projectCtx); err != nil {
+			response.NewErrorResponse(c, log, err)
+			return
+		}
+	}
+
+	limit, err := getQueryInt(c, limitCtx, defaultLimit)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	offset, err := getQueryInt(c, offsetCtx, defaultOffset)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	includeRemoved, err := getQueryBool(c, includeRemovedCtx, true)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	output, err := h.serviceProvider.GetGoods(c, projectID, limit, offset, includeRemoved)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *GoodHandler) RestoreGood(c *gin.Context) {
+	const op = "handlers.RestoreGood"
+
+	log := h.log.With(slog.String("op", op))
+
+	id, err := getID(c, idCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
 
-	limit, err := getID(c, limitCtx)
+	input := models.RestoreRequest{ID: id, ProjectID: projectID}
+
+	output, err := h.serviceProvider.RestoreGood(c, &input)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
-	offset, err = getID(c, offsetCtx)
+	setETag(c, output.Version)
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *GoodHandler) PurgeGoods(c *gin.Context) {
+	const op = "handlers.PurgeGoods"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	var input models.PurgeRequest
+	if err := bindOptionalJSON(c, &input); err != nil {
+		response.NewErrorResponse(c, log, validationError(err))
+		return
 	}
 
-	output, err := h.serviceProvider.GetGoods(c, limit, offset)
+	input.ProjectID = projectID
+
+	output, err := h.serviceProvider.PurgeGoods(c, &input)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusInternalServerError, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, output)
@@ -189,40 +446,284 @@ func (h *GoodHandler) ReprioritizeGood(c *gin.Context) {
 
 	id, err := getID(c, idCtx)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
 	projectId, err := getID(c, projectCtx)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, err.Error())
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
 	var input models.ReprioritizeRequest
-	if err := c.BindJSON(&input); err != nil {
-		response.NewErrorResponse(c, log, http.StatusBadRequest, "invalid input body")
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.NewErrorResponse(c, log, validationError(err))
+		return
 	}
 
 	input.ID = id
 	input.ProjectID = projectId
 
+	if err := applyIfMatch(c, &input.Version); err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
 	output, err := h.serviceProvider.ReprioritizeGood(c, &input)
 	if err != nil {
-		response.NewErrorResponse(c, log, http.StatusInternalServerError, "internal error")
+		response.NewErrorResponse(c, log, err)
+		return
 	}
 
+	for _, value := range output.Priorities {
+		if value.ID == input.ID {
+			setETag(c, value.Version)
+		}
+	}
 	c.JSON(http.StatusOK, output)
 }
 
+func (h *GoodHandler) SearchGoods(c *gin.Context) {
+	const op = "handlers.SearchGoods"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	query := strings.TrimSpace(c.Query(queryCtx))
+	if query == "" {
+		response.NewErrorResponse(c, log, errInvalidParam.WithField(queryCtx, apperr.CodeRequired))
+		return
+	}
+
+	limit, err := getQueryInt(c, limitCtx, defaultLimit)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	offset, err := getQueryInt(c, offsetCtx, defaultSearchOffset)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	includeRemoved, err := getQueryBool(c, includeRemovedCtx, false)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	input := models.SearchRequest{
+		ProjectID:      projectID,
+		Query:          query,
+		Limit:          limit,
+		Offset:         offset,
+		IncludeRemoved: includeRemoved,
+	}
+
+	output, err := h.serviceProvider.SearchGoods(c, &input)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *GoodHandler) SuggestGoods(c *gin.Context) {
+	const op = "handlers.SuggestGoods"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	query := strings.TrimSpace(c.Query(queryCtx))
+	if query == "" {
+		response.NewErrorResponse(c, log, errInvalidParam.WithField(queryCtx, apperr.CodeRequired))
+		return
+	}
+
+	limit, err := getQueryInt(c, limitCtx, defaultLimit)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	input := models.SuggestRequest{
+		ProjectID: projectID,
+		Query:     query,
+		Limit:     limit,
+	}
+
+	output, err := h.serviceProvider.SuggestGoods(c, &input)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *GoodHandler) BatchGoods(c *gin.Context) {
+	const op = "handlers.BatchGoods"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	var input models.BatchRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.NewErrorResponse(c, log, validationError(err))
+		return
+	}
+
+	input.ProjectID = projectID
+
+	output, err := h.serviceProvider.BatchGoods(c, &input)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	if !output.Applied {
+		c.JSON(http.StatusUnprocessableEntity, output)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetChanges serves delta sync: upserts and tombstones since the token returned
+// by the previous call.
+func (h *GoodHandler) GetChanges(c *gin.Context) {
+	const op = "handlers.GetChanges"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	limit, err := getQueryInt(c, limitCtx, defaultChangesLimit)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	input := models.ChangesRequest{
+		ProjectID: projectID,
+		Since:     c.Query(sinceCtx),
+		Limit:     limit,
+	}
+
+	output, err := h.serviceProvider.GetChanges(c, &input)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// getID reads the id from the path on the /v1 routes and from the query
+// string on the legacy ones.
 func getID(c *gin.Context, param string) (int, error) {
-	id, ok := c.Get(param)
+	value := c.Param(param)
+	if value == "" {
+		value = c.Query(param)
+	}
+
+	if value == "" {
+		return 0, errInvalidParam.WithField(param, apperr.CodeRequired)
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, errInvalidParam.WithField(param, apperr.CodeInteger)
+	}
+
+	return id, nil
+}
+
+// bindOptionalJSON binds the body when there is one, so that requests whose
+// body only carries optional fields may omit it.
+func bindOptionalJSON(c *gin.Context, input any) error {
+	if c.Request.ContentLength == 0 {
+		return binding.Validator.ValidateStruct(input)
+	}
+
+	return c.ShouldBindJSON(input)
+}
+
+func getQueryInt(c *gin.Context, param string, defaultValue int) (int, error) {
+	value, ok := c.GetQuery(param)
 	if !ok {
-		return 0, errors.New(fmt.Sprintf("no %s in query", param))
+		return defaultValue, nil
+	}
+
+	res, err := strconv.Atoi(value)
+	if err != nil || res < 0 {
+		return 0, errInvalidParam.WithField(param, apperr.CodeInteger)
 	}
 
-	idInt, ok := id.(int)
+	return res, nil
+}
+
+func getQueryBool(c *gin.Context, param string, defaultValue bool) (bool, error) {
+	value, ok := c.GetQuery(param)
 	if !ok {
-		return 0, errors.New(fmt.Sprintf("%s is of invalid type", param))
+		return defaultValue, nil
+	}
+
+	res, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errInvalidParam.WithField(param, apperr.CodeBoolean)
 	}
 
-	return idInt, nil
+	return res, nil
+}
+
+// applyIfMatch overrides the expected version with the one from the If-Match
+// header. Both strong ("3") and weak (W/"3") tags are accepted; "*" matches any version.
+func applyIfMatch(c *gin.Context, version *int) error {
+	value := strings.TrimSpace(c.GetHeader(ifMatchHeader))
+	if value == "" {
+		return nil
+	}
+
+	if value == "*" {
+		*version = 0
+		return nil
+	}
+
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+
+	res, err := strconv.Atoi(value)
+	if err != nil || res <= 0 {
+		return errInvalidParam.WithField(ifMatchHeader, apperr.CodeETag)
+	}
+
+	*version = res
+
+	return nil
+}
+
+func setETag(c *gin.Context, version int) {
+	c.Header(eTagHeader, fmt.Sprintf(`"%d"`, version))
 }
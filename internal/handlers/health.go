@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/breaker"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	livenessPath  = "/healthz"
+	readinessPath = "/readyz"
+	metricsPath   = "/debug/vars"
+
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+)
+
+type HealthProvider interface {
+	BreakerStats() breaker.Stats
+	Ready(ctx context.Context) *models.Readiness
+}
+
+type healthResponse struct {
+	Status string        `json:"status"`
+	Cache  breaker.Stats `json:"cache"`
+}
+
+// Liveness only tells that the process serves requests, so it never fails on
+// the dependencies. An open cache breaker is reported as degraded: requests
+// are served from storage.
+func (h *GoodHandler) Liveness(c *gin.Context) {
+	output := healthResponse{
+		Status: healthOK,
+		Cache:  h.healthProvider.BreakerStats(),
+	}
+
+	if output.Cache.State == breaker.Open.String() {
+		output.Status = healthDegraded
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// Readiness checks the dependencies and the migrations. It fails with 503 when
+// a required dependency is down; optional ones only degrade it.
+func (h *GoodHandler) Readiness(c *gin.Context) {
+	output := h.healthProvider.Ready(c.Request.Context())
+
+	status := http.StatusOK
+	if output.Status == models.ReadinessUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, output)
+}
+
+// Metrics serves the expvar variables, the runtime memory stats included.
+func (h *GoodHandler) Metrics(c *gin.Context) {
+	expvar.Handler().ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type IdempotencyProvider interface {
+	StartIdempotent(ctx context.Context, key string, record *models.IdempotencyRecord, ttl time.Duration) (*models.IdempotencyRecord, bool, error)
+	SaveIdempotent(ctx context.Context, key string, record *models.IdempotencyRecord, ttl time.Duration) error
+	DeleteIdempotent(ctx context.Context, key string) error
+}
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+)
+
+var (
+	errIdempotencyKeyReused  = apperr.New(apperr.Unprocessable, "errors.idempotency.KeyReused", "idempotency key was used with another request")
+	errIdempotencyInProgress = apperr.New(apperr.Conflict, "errors.idempotency.InProgress", "request with this idempotency key is in progress")
+	errIdempotencyKeyTooLong = apperr.New(apperr.Validation, "errors.idempotency.KeyTooLong", "idempotency key is too long")
+)
+
+// replayedHeaders are the response headers stored along with the body.
+var replayedHeaders = []string{"Content-Type", eTagHeader}
+
+// bodyRecorder keeps a copy of everything written to the response.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Idempotency replays the stored response for a repeated Idempotency-Key.
+// A key reused with another method, path or body is rejected, as is a key whose
+// first request is still in flight. Server errors are not stored so that the
+// client can retry them. Requests without the header pass through untouched,
+// and so do all requests while the store is unreachable.
+func Idempotency(log *slog.Logger, provider IdempotencyProvider, cfg config.Idempotency) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.Idempotency"
+
+		log := log.With(slog.String("op", op))
+
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			response.NewErrorResponse(c, log, errIdempotencyKeyTooLong)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			response.NewErrorResponse(c, log, errInvalidBody)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &models.IdempotencyRecord{Fingerprint: fingerprint(c.Request, body)}
+
+		stored, started, err := provider.StartIdempotent(c, key, record, cfg.LockTTL)
+		if err != nil {
+			log.Warn("couldn't check idempotency key, processing request as is", slog.String("error", err.Error()))
+			c.Next()
+			return
+		}
+
+		if !started {
+			switch {
+			case stored.Fingerprint != record.Fingerprint:
+				response.NewErrorResponse(c, log, errIdempotencyKeyReused)
+			case !stored.Completed:
+				response.NewErrorResponse(c, log, errIdempotencyInProgress)
+			default:
+				for name, value := range stored.Header {
+					c.Header(name, value)
+				}
+				c.Header(idempotencyReplayedHeader, "true")
+				c.Data(stored.StatusCode, stored.Header["Content-Type"], stored.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// the request context may already be cancelled at this point
+		ctx := context.Background()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := provider.DeleteIdempotent(ctx, key); err != nil {
+				log.Warn("couldn't release idempotency key", slog.String("error", err.Error()))
+			}
+			return
+		}
+
+		record.Completed = true
+		record.StatusCode = recorder.Status()
+		record.Body = recorder.body.Bytes()
+		record.Header = make(map[string]string, len(replayedHeaders))
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				record.Header[name] = value
+			}
+		}
+
+		if err := provider.SaveIdempotent(ctx, key, record, cfg.TTL); err != nil {
+			log.Warn("couldn't save idempotent response", slog.String("error", err.Error()))
+		}
+	}
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{'\n'})
+	hash.Write([]byte(r.URL.RequestURI()))
+	hash.Write([]byte{'\n'})
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
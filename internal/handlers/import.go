@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	formatCtx  = "format"
+	modeCtx    = "mode"
+	keyCtx     = "key"
+	dryRunCtx  = "dry_run"
+	mappingCtx = "map"
+
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+)
+
+// ImportGoods streams the request body into the project. The format comes from
+// the format query param or else from the content type. Column mapping is given
+// as map=name:Title,description:Body,sku:Article.
+func (h *GoodHandler) ImportGoods(c *gin.Context) {
+	const op = "handlers.ImportGoods"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	dryRun, err := getQueryBool(c, dryRunCtx, false)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	mapping, err := parseMapping(c.Query(mappingCtx))
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	input := models.ImportRequest{
+		ProjectID: projectID,
+		Format:    importFormat(c),
+		Mode:      c.Query(modeCtx),
+		Key:       c.Query(keyCtx),
+		DryRun:    dryRun,
+		Mapping:   mapping,
+	}
+
+	output, err := h.importProvider.ImportGoods(c, &input, c.Request.Body)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func importFormat(c *gin.Context) string {
+	if format := c.Query(formatCtx); format != "" {
+		return format
+	}
+
+	contentType, _, _ := mime.ParseMediaType(c.ContentType())
+	switch contentType {
+	case contentTypeCSV:
+		return models.ImportFormatCSV
+	case contentTypeNDJSON:
+		return models.ImportFormatNDJSON
+	}
+
+	return ""
+}
+
+func parseMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if value == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		field, column, ok := strings.Cut(pair, ":")
+		if !ok || field == "" || column == "" {
+			return nil, errInvalidParam.WithField(mappingCtx, apperr.CodeMapping)
+		}
+
+		mapping[strings.TrimSpace(field)] = strings.TrimSpace(column)
+	}
+
+	return mapping, nil
+}
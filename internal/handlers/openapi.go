@@ -0,0 +1,493 @@
+package handlers
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/lib/openapi"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	openAPIPath = "/openapi.json"
+	docsPath    = "/docs"
+
+	contentTypeHTML = "text/html; charset=utf-8"
+)
+
+//go:embed docs/index.html
+var docsPage []byte
+
+type apiParam struct {
+	name     string
+	kind     string
+	required bool
+}
+
+type apiContent struct {
+	contentType string
+	model       any
+}
+
+type apiResponse struct {
+	status  int
+	content []apiContent
+}
+
+// apiOperation documents one route. Path params come from the path itself;
+// models are turned into schemas by reflection, so they can't go stale.
+type apiOperation struct {
+	method      string
+	path        string
+	id          string
+	summary     string
+	tag         string
+	query       []apiParam
+	request     []apiContent
+	optional    bool
+	responses   []apiResponse
+	idempotent  bool
+	conditional bool
+	deprecated  bool
+}
+
+func jsonContent(model any) []apiContent {
+	return []apiContent{{contentType: contentTypeJSON, model: model}}
+}
+
+func ok(model any) []apiResponse {
+	return []apiResponse{{status: http.StatusOK, content: jsonContent(model)}}
+}
+
+var (
+	stringSchema = &openapi.Schema{Type: "string"}
+	binarySchema = &openapi.Schema{Type: "string", Format: "binary"}
+
+	limitParam          = apiParam{name: limitCtx, kind: "integer"}
+	offsetParam         = apiParam{name: offsetCtx, kind: "integer"}
+	includeRemovedParam = apiParam{name: includeRemovedCtx, kind: "boolean"}
+	queryParam          = apiParam{name: queryCtx, kind: "string", required: true}
+)
+
+var apiOperations = []apiOperation{
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/goods", id: "listGoods", tag: "goods",
+		summary:   "List goods of a project by id range",
+		query:     []apiParam{limitParam, offsetParam, includeRemovedParam},
+		responses: ok(models.ListGoodsResponse{}),
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/goods/:id", id: "getGood", tag: "goods",
+		summary:   "Get a good",
+		responses: ok(models.Good{}),
+	},
+	{
+		method: http.MethodPost, path: "/v1/projects/:projectId/goods", id: "createGood", tag: "goods",
+		summary:    "Create a good",
+		request:    jsonContent(models.CreateRequest{}),
+		responses:  ok(models.Good{}),
+		idempotent: true,
+	},
+	{
+		method: http.MethodPatch, path: "/v1/projects/:projectId/goods/:id", id: "updateGood", tag: "goods",
+		summary: "Update a good with a JSON Merge Patch or a JSON Patch",
+		request: []apiContent{
+			{contentType: contentTypeJSON, model: models.UpdateRequest{}},
+			{contentType: contentTypeMergePatch, model: models.UpdateRequest{}},
+			{contentType: contentTypeJSONPatch, model: []models.PatchOperation{}},
+		},
+		responses:   ok(models.Good{}),
+		idempotent:  true,
+		conditional: true,
+	},
+	{
+		method: http.MethodDelete, path: "/v1/projects/:projectId/goods/:id", id: "deleteGood", tag: "goods",
+		summary:     "Remove a good",
+		request:     jsonContent(models.DeleteRequest{}),
+		optional:    true,
+		responses:   ok(models.DeleteResponse{}),
+		idempotent:  true,
+		conditional: true,
+	},
+	{
+		method: http.MethodPatch, path: "/v1/projects/:projectId/goods/:id/priority", id: "reprioritizeGood", tag: "goods",
+		summary:     "Move a good to a new priority",
+		request:     jsonContent(models.ReprioritizeRequest{}),
+		responses:   ok(models.ReprioritizeResponse{}),
+		idempotent:  true,
+		conditional: true,
+	},
+	{
+		method: http.MethodPost, path: "/v1/projects/:projectId/goods/:id/restore", id: "restoreGood", tag: "goods",
+		summary:   "Restore a removed good",
+		responses: ok(models.Good{}),
+	},
+	{
+		method: http.MethodDelete, path: "/v1/projects/:projectId/goods/removed", id: "purgeGoods", tag: "goods",
+		summary:   "Permanently delete goods removed more than days ago",
+		request:   jsonContent(models.PurgeRequest{}),
+		responses: ok(models.PurgeResponse{}),
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/goods/search", id: "searchGoods", tag: "goods",
+		summary:   "Full-text search over names and descriptions",
+		query:     []apiParam{queryParam, limitParam, offsetParam, includeRemovedParam},
+		responses: ok(models.SearchResponse{}),
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/goods/suggest", id: "suggestGoods", tag: "goods",
+		summary:   "Suggest good names by prefix",
+		query:     []apiParam{queryParam, limitParam},
+		responses: ok(models.SuggestResponse{}),
+	},
+	{
+		method: http.MethodPost, path: "/v1/projects/:projectId/goods/batch", id: "batchGoods", tag: "goods",
+		summary: "Create, update and delete goods in one request",
+		request: jsonContent(models.BatchRequest{}),
+		responses: []apiResponse{
+			{status: http.StatusOK, content: jsonContent(models.BatchResponse{})},
+			{status: http.StatusUnprocessableEntity, content: jsonContent(models.BatchResponse{})},
+		},
+		idempotent: true,
+	},
+	{
+		method: http.MethodPost, path: "/v1/projects/:projectId/goods/import", id: "importGoods", tag: "goods",
+		summary: "Import goods from CSV or NDJSON",
+		query: []apiParam{
+			{name: formatCtx, kind: "string"},
+			{name: modeCtx, kind: "string"},
+			{name: keyCtx, kind: "string"},
+			{name: dryRunCtx, kind: "boolean"},
+			{name: mappingCtx, kind: "string"},
+		},
+		request: []apiContent{
+			{contentType: contentTypeCSV, model: stringSchema},
+			{contentType: contentTypeNDJSON, model: stringSchema},
+		},
+		responses: ok(models.ImportReport{}),
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/goods/export", id: "exportGoods", tag: "goods",
+		summary: "Export goods as CSV, NDJSON or XLSX",
+		query: []apiParam{
+			{name: formatCtx, kind: "string"},
+			includeRemovedParam,
+			{name: nameCtx, kind: "string"},
+			{name: createdAfterCtx, kind: "string"},
+			{name: createdBeforeCtx, kind: "string"},
+		},
+		responses: []apiResponse{{status: http.StatusOK, content: []apiContent{
+			{contentType: contentTypeCSV, model: stringSchema},
+			{contentType: contentTypeNDJSON, model: stringSchema},
+			{contentType: contentTypeXLSX, model: binarySchema},
+		}}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/goods/changes", id: "getGoodsChanges", tag: "goods",
+		summary:   "Get goods changed since the token of the previous sync, removed ones as tombstones",
+		query:     []apiParam{{name: sinceCtx, kind: "string"}, limitParam},
+		responses: ok(models.ChangesResponse{}),
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/goods/stream", id: "streamGoods", tag: "goods",
+		summary: "Follow good changes as Server-Sent Events",
+		query:   []apiParam{{name: lastEventIDCtx, kind: "string"}},
+		responses: []apiResponse{{status: http.StatusOK, content: []apiContent{
+			{contentType: contentTypeEventStream, model: models.FeedEvent{}},
+		}}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/goods/ws", id: "streamGoodsWS", tag: "goods",
+		summary:   "Follow good changes over WebSocket, one JSON message per event",
+		query:     []apiParam{{name: lastEventIDCtx, kind: "string"}},
+		responses: []apiResponse{{status: http.StatusSwitchingProtocols}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/rules", id: "getProjectRules", tag: "projects",
+		summary:   "Get the rules goods of the project must follow",
+		responses: ok(models.ProjectRules{}),
+	},
+	{
+		method: http.MethodPut, path: "/v1/projects/:projectId/rules", id: "updateProjectRules", tag: "projects",
+		summary:   "Replace the rules of the project",
+		request:   jsonContent(models.ProjectRules{}),
+		responses: ok(models.ProjectRules{}),
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/webhooks", id: "listWebhooks", tag: "webhooks",
+		summary:   "List the webhooks of the project",
+		responses: ok([]models.Webhook{}),
+	},
+	{
+		method: http.MethodPost, path: "/v1/projects/:projectId/webhooks", id: "createWebhook", tag: "webhooks",
+		summary:   "Subscribe a URL to good events, the secret is returned only here",
+		request:   jsonContent(models.WebhookRequest{}),
+		responses: ok(models.Webhook{}),
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/webhooks/:id", id: "getWebhook", tag: "webhooks",
+		summary:   "Get a webhook",
+		responses: ok(models.Webhook{}),
+	},
+	{
+		method: http.MethodPut, path: "/v1/projects/:projectId/webhooks/:id", id: "updateWebhook", tag: "webhooks",
+		summary:   "Replace a webhook, enabling it resets its failures",
+		request:   jsonContent(models.WebhookRequest{}),
+		responses: ok(models.Webhook{}),
+	},
+	{
+		method: http.MethodDelete, path: "/v1/projects/:projectId/webhooks/:id", id: "deleteWebhook", tag: "webhooks",
+		summary:   "Delete a webhook and its delivery log",
+		responses: []apiResponse{{status: http.StatusNoContent}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/projects/:projectId/webhooks/:id/deliveries", id: "listWebhookDeliveries", tag: "webhooks",
+		summary:   "List the delivery attempts of a webhook, newest first",
+		query:     []apiParam{limitParam, offsetParam},
+		responses: ok([]models.WebhookDelivery{}),
+	},
+	{
+		method: http.MethodGet, path: openAPIPath, id: "getOpenAPI", tag: "docs",
+		summary:   "This document",
+		responses: []apiResponse{{status: http.StatusOK, content: jsonContent(&openapi.Schema{Type: "object"})}},
+	},
+	{
+		method: http.MethodGet, path: livenessPath, id: "getLiveness", tag: "ops",
+		summary:   "Liveness probe, reports whether the application runs degraded with the cache circuit breaker",
+		responses: ok(healthResponse{}),
+	},
+	{
+		method: http.MethodGet, path: readinessPath, id: "getReadiness", tag: "ops",
+		summary: "Readiness probe with the status and latency of every dependency, 503 when a required one is down",
+		responses: []apiResponse{
+			{status: http.StatusOK, content: jsonContent(models.Readiness{})},
+			{status: http.StatusServiceUnavailable, content: jsonContent(models.Readiness{})},
+		},
+	},
+	{
+		method: http.MethodGet, path: metricsPath, id: "getMetrics", tag: "ops",
+		summary:   "Runtime and circuit breaker metrics as expvar JSON",
+		responses: []apiResponse{{status: http.StatusOK, content: jsonContent(&openapi.Schema{Type: "object"})}},
+	},
+	{
+		method: http.MethodGet, path: docsPath, id: "getDocs", tag: "docs",
+		summary:   "Interactive documentation",
+		responses: []apiResponse{{status: http.StatusOK, content: []apiContent{{contentType: contentTypeHTML, model: stringSchema}}}},
+	},
+}
+
+// legacyRoutes maps operations to their pre-/v1 paths. Ids missing from a
+// legacy path are passed in the query string instead, where optionalIDs makes
+// them filters. operationID tells apart several legacy paths of an operation.
+var legacyRoutes = []struct {
+	id          string
+	path        string
+	operationID string
+	optionalIDs bool
+}{
+	{id: "listGoods", path: "/goods/list", optionalIDs: true},
+	{id: "createGood", path: "/good/create"},
+	{id: "createGood", path: "/good/create/:projectId", operationID: "createGoodInPathLegacy"},
+	{id: "updateGood", path: "/good/update"},
+	{id: "deleteGood", path: "/good/delete"},
+	{id: "reprioritizeGood", path: "/good/reprioritize"},
+	{id: "restoreGood", path: "/good/restore"},
+	{id: "purgeGoods", path: "/good/purge"},
+	{id: "searchGoods", path: "/project/:projectId/goods/search"},
+	{id: "suggestGoods", path: "/project/:projectId/goods/suggest"},
+	{id: "batchGoods", path: "/project/:projectId/goods/batch"},
+	{id: "importGoods", path: "/project/:projectId/goods/import"},
+	{id: "exportGoods", path: "/project/:projectId/goods/export"},
+	{id: "getGoodsChanges", path: "/project/:projectId/goods/changes"},
+	{id: "streamGoods", path: "/project/:projectId/goods/stream"},
+	{id: "streamGoodsWS", path: "/project/:projectId/goods/ws"},
+	{id: "getProjectRules", path: "/project/:projectId/rules"},
+	{id: "updateProjectRules", path: "/project/:projectId/rules"},
+}
+
+// OpenAPI builds the specification of every route registered by InitRoutes.
+func OpenAPI() *openapi.Document {
+	generator := openapi.NewGenerator()
+	generator.Override(models.OptionalString{}, &openapi.Schema{Type: "string", Nullable: true})
+
+	doc := &openapi.Document{
+		OpenAPI: "3.0.3",
+		Info:    openapi.Info{Title: "hezzl-task", Version: "1.0.0"},
+		Paths:   make(map[string]openapi.PathItem),
+	}
+
+	byID := make(map[string]apiOperation, len(apiOperations))
+	operations := make([]apiOperation, 0, len(apiOperations)+len(legacyRoutes))
+	for _, operation := range apiOperations {
+		byID[operation.id] = operation
+		operations = append(operations, operation)
+	}
+
+	for _, route := range legacyRoutes {
+		operation := byID[route.id]
+
+		for _, param := range pathParams(operation.path) {
+			if !strings.Contains(route.path, ":"+param) {
+				operation.query = append([]apiParam{{name: param, kind: "integer", required: !route.optionalIDs}}, operation.query...)
+			}
+		}
+
+		operation.id += "Legacy"
+		if route.operationID != "" {
+			operation.id = route.operationID
+		}
+		operation.path = route.path
+		operation.deprecated = true
+		operations = append(operations, operation)
+	}
+
+	for _, operation := range operations {
+		path := openAPIPathOf(operation.path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(openapi.PathItem)
+		}
+		doc.Paths[path][strings.ToLower(operation.method)] = buildOperation(generator, operation)
+	}
+
+	doc.Components.Schemas = generator.Schemas()
+
+	return doc
+}
+
+func buildOperation(generator *openapi.Generator, operation apiOperation) *openapi.Operation {
+	res := &openapi.Operation{
+		OperationID: operation.id,
+		Summary:     operation.summary,
+		Tags:        []string{operation.tag},
+		Deprecated:  operation.deprecated,
+		Responses:   make(map[string]openapi.Response),
+	}
+
+	for _, param := range pathParams(operation.path) {
+		res.Parameters = append(res.Parameters, openapi.Parameter{
+			Name: param, In: "path", Required: true, Schema: &openapi.Schema{Type: "integer"},
+		})
+	}
+
+	for _, param := range operation.query {
+		res.Parameters = append(res.Parameters, openapi.Parameter{
+			Name: param.name, In: "query", Required: param.required, Schema: &openapi.Schema{Type: param.kind},
+		})
+	}
+
+	if operation.idempotent {
+		res.Parameters = append(res.Parameters, openapi.Parameter{
+			Name: idempotencyKeyHeader, In: "header", Schema: &openapi.Schema{Type: "string"},
+		})
+	}
+
+	if operation.conditional {
+		res.Parameters = append(res.Parameters, openapi.Parameter{
+			Name: ifMatchHeader, In: "header", Schema: &openapi.Schema{Type: "string"},
+		})
+	}
+
+	if len(operation.request) > 0 {
+		res.RequestBody = &openapi.RequestBody{
+			Required: !operation.optional,
+			Content:  buildContent(generator, operation.request),
+		}
+	}
+
+	for _, value := range operation.responses {
+		res.Responses[strconv.Itoa(value.status)] = openapi.Response{
+			Description: http.StatusText(value.status),
+			Content:     buildContent(generator, value.content),
+		}
+	}
+
+	res.Responses["default"] = openapi.Response{
+		Description: "Error",
+		Content:     buildContent(generator, jsonContent(response.ErrorResponse{})),
+	}
+
+	return res
+}
+
+func buildContent(generator *openapi.Generator, content []apiContent) map[string]openapi.MediaType {
+	res := make(map[string]openapi.MediaType, len(content))
+
+	for _, value := range content {
+		schema, ok := value.model.(*openapi.Schema)
+		if !ok {
+			schema = generator.Schema(value.model)
+		}
+
+		res[value.contentType] = openapi.MediaType{Schema: schema}
+	}
+
+	return res
+}
+
+func pathParams(path string) []string {
+	var params []string
+
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			params = append(params, strings.TrimPrefix(segment, ":"))
+		}
+	}
+
+	return params
+}
+
+// openAPIPathOf turns gin's :param segments into OpenAPI {param} templates.
+func openAPIPathOf(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimPrefix(segment, ":") + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// CheckOpenAPI lists the differences between the registered routes and the
+// specification; an empty result means they are in sync.
+func CheckOpenAPI(routes gin.RoutesInfo, doc *openapi.Document) []string {
+	var problems []string
+
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		key := route.Method + " " + openAPIPathOf(route.Path)
+		registered[key] = true
+
+		if doc.Paths[openAPIPathOf(route.Path)][strings.ToLower(route.Method)] == nil {
+			problems = append(problems, fmt.Sprintf("route %s is not documented", key))
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			key := strings.ToUpper(method) + " " + path
+			if !registered[key] {
+				problems = append(problems, fmt.Sprintf("documented operation %s has no route", key))
+			}
+		}
+	}
+
+	sort.Strings(problems)
+
+	return problems
+}
+
+func (h *GoodHandler) GetOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, h.openAPI)
+}
+
+func (h *GoodHandler) GetDocs(c *gin.Context) {
+	c.Data(http.StatusOK, contentTypeHTML, docsPage)
+}
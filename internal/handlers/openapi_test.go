@@ -0,0 +1,43 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// TestOpenAPIMatchesRoutes fails when a route is added or removed without
+// updating the specification, or the other way round.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	router, _ := newRouter(t)
+
+	for _, problem := range handlers.CheckOpenAPI(router.Routes(), handlers.OpenAPI()) {
+		t.Error(problem)
+	}
+}
+
+func TestCheckOpenAPIReportsDrift(t *testing.T) {
+	router, _ := newRouter(t)
+
+	routes := append(router.Routes(), gin.RouteInfo{Method: http.MethodGet, Path: "/v1/undocumented/:id"})
+
+	doc := handlers.OpenAPI()
+	delete(doc.Paths, "/v1/projects/{projectId}/goods/{id}/restore")
+
+	problems := handlers.CheckOpenAPI(routes, doc)
+
+	want := []string{
+		"route GET /v1/undocumented/{id} is not documented",
+		"route POST /v1/projects/{projectId}/goods/{id}/restore is not documented",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got problems %q, want %q", problems, want)
+	}
+	for i := range want {
+		if problems[i] != want[i] {
+			t.Errorf("problem %d is %q, want %q", i, problems[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	contentTypeJSON       = "application/json"
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+
+	patchOpAdd     = "add"
+	patchOpReplace = "replace"
+	patchOpRemove  = "remove"
+	patchOpTest    = "test"
+
+	patchPathName        = "/name"
+	patchPathDescription = "/description"
+	patchPathVersion     = "/version"
+)
+
+// bindUpdateRequest fills input from either a JSON Merge Patch (RFC 7396) or a
+// JSON Patch (RFC 6902) document, depending on the request content type.
+// Plain application/json is treated as a merge patch.
+func bindUpdateRequest(c *gin.Context, input *models.UpdateRequest) error {
+	contentType, _, err := mime.ParseMediaType(c.ContentType())
+	if err != nil && c.ContentType() != "" {
+		return errUnsupportedMediaType
+	}
+
+	switch contentType {
+	case "", contentTypeJSON, contentTypeMergePatch:
+		if err := json.NewDecoder(c.Request.Body).Decode(input); err != nil {
+			return errInvalidBody
+		}
+	case contentTypeJSONPatch:
+		var ops []models.PatchOperation
+		if err := json.NewDecoder(c.Request.Body).Decode(&ops); err != nil {
+			return errInvalidBody
+		}
+
+		if err := applyJSONPatch(input, ops); err != nil {
+			return err
+		}
+	default:
+		return errUnsupportedMediaType
+	}
+
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		return validationError(err)
+	}
+
+	return nil
+}
+
+func applyJSONPatch(input *models.UpdateRequest, ops []models.PatchOperation) error {
+	for i, operation := range ops {
+		var target *models.OptionalString
+
+		switch operation.Path {
+		case patchPathName:
+			target = &input.Name
+			if operation.Op == patchOpTest {
+				target = &input.TestName
+			}
+		case patchPathDescription:
+			target = &input.Description
+			if operation.Op == patchOpTest {
+				target = &input.TestDescription
+			}
+		case patchPathVersion:
+			if operation.Op != patchOpTest {
+				return errInvalidBody.WithField(fmt.Sprintf("[%d].path", i), apperr.CodeReadOnly)
+			}
+
+			version, err := strconv.Atoi(string(operation.Value))
+			if err != nil {
+				return errInvalidBody.WithField(fmt.Sprintf("[%d].value", i), apperr.CodeType)
+			}
+			input.Version = version
+
+			continue
+		default:
+			return errInvalidBody.WithField(fmt.Sprintf("[%d].path", i), apperr.CodeUnknownValue, operation.Path)
+		}
+
+		switch operation.Op {
+		case patchOpAdd, patchOpReplace, patchOpTest:
+			if len(operation.Value) == 0 {
+				return errInvalidBody.WithField(fmt.Sprintf("[%d].value", i), apperr.CodeRequired)
+			}
+
+			if err := json.Unmarshal(operation.Value, target); err != nil {
+				return errInvalidBody.WithField(fmt.Sprintf("[%d].value", i), apperr.CodeType)
+			}
+		case patchOpRemove:
+			*target = models.OptionalString{Set: true}
+		default:
+			return errInvalidBody.WithField(fmt.Sprintf("[%d].op", i), apperr.CodeUnknownValue, operation.Op)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package handlers_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/IskanderSh/hezzl-task/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	patchTarget    = "/v1/projects/7/goods/3"
+	jsonPatch      = "application/json-patch+json"
+	mergePatch     = "application/merge-patch+json"
+	jsonContent    = "application/json"
+	unknownContent = "text/plain"
+)
+
+func servePatch(router *gin.Engine, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, patchTarget, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+type patchCase struct {
+	name        string
+	contentType string
+	body        string
+	status      int
+	want        *models.UpdateRequest
+}
+
+func runPatchCases(t *testing.T, cases []patchCase) {
+	t.Helper()
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router, service := newRouter(t)
+
+			w := servePatch(router, tc.contentType, tc.body)
+			if w.Code != tc.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tc.status, w.Body.String())
+			}
+
+			if tc.want == nil {
+				return
+			}
+
+			got := *service.update
+			got.ID, got.ProjectID = 0, 0
+			if got != *tc.want {
+				t.Errorf("request = %+v, want %+v", got, *tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdateGoodJSONPatch(t *testing.T) {
+	runPatchCases(t, []patchCase{
+		{
+			name:        "replace name",
+			contentType: jsonPatch,
+			body:        `[{"op":"replace","path":"/name","value":"renamed"}]`,
+			status:      http.StatusOK,
+			want:        &models.UpdateRequest{Name: models.NewOptionalString("renamed")},
+		},
+		{
+			name:        "add description",
+			contentType: jsonPatch,
+			body:        `[{"op":"add","path":"/description","value":"new"}]`,
+			status:      http.StatusOK,
+			want:        &models.UpdateRequest{Description: models.NewOptionalString("new")},
+		},
+		{
+			name:        "remove description",
+			contentType: jsonPatch,
+			body:        `[{"op":"remove","path":"/description"}]`,
+			status:      http.StatusOK,
+			want:        &models.UpdateRequest{Description: models.OptionalString{Set: true}},
+		},
+		{
+			name:        "remove name",
+			contentType: jsonPatch,
+			body:        `[{"op":"remove","path":"/name"}]`,
+			status:      http.StatusOK,
+			want:        &models.UpdateRequest{Name: models.OptionalString{Set: true}},
+		},
+		{
+			name:        "test matches",
+			contentType: jsonPatch,
+			body:        `[{"op":"test","path":"/name","value":"good"},{"op":"test","path":"/version","value":1},{"op":"replace","path":"/name","value":"renamed"}]`,
+			status:      http.StatusOK,
+			want: &models.UpdateRequest{
+				Name:     models.NewOptionalString("renamed"),
+				Version:  1,
+				TestName: models.NewOptionalString("good"),
+			},
+		},
+		{
+			name:        "test mismatch",
+			contentType: jsonPatch,
+			body:        `[{"op":"test","path":"/name","value":"other"},{"op":"replace","path":"/name","value":"renamed"}]`,
+			status:      http.StatusPreconditionFailed,
+		},
+		{
+			name:        "replace version",
+			contentType: jsonPatch,
+			body:        `[{"op":"replace","path":"/version","value":2}]`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "missing value",
+			contentType: jsonPatch,
+			body:        `[{"op":"replace","path":"/name"}]`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "value of wrong type",
+			contentType: jsonPatch,
+			body:        `[{"op":"replace","path":"/name","value":1}]`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "unknown path",
+			contentType: jsonPatch,
+			body:        `[{"op":"replace","path":"/priority","value":1}]`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "unknown op",
+			contentType: jsonPatch,
+			body:        `[{"op":"move","path":"/name","value":"renamed"}]`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "blank name",
+			contentType: jsonPatch,
+			body:        `[{"op":"replace","path":"/name","value":" "}]`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "not an array",
+			contentType: jsonPatch,
+			body:        `{"op":"replace","path":"/name","value":"renamed"}`,
+			status:      http.StatusBadRequest,
+		},
+	})
+}
+
+func TestUpdateGoodMergePatch(t *testing.T) {
+	runPatchCases(t, []patchCase{
+		{
+			name:        "merge patch name",
+			contentType: mergePatch,
+			body:        `{"name":"renamed"}`,
+			status:      http.StatusOK,
+			want:        &models.UpdateRequest{Name: models.NewOptionalString("renamed")},
+		},
+		{
+			name:        "plain json",
+			contentType: jsonContent,
+			body:        `{"description":"new","version":1}`,
+			status:      http.StatusOK,
+			want:        &models.UpdateRequest{Description: models.NewOptionalString("new"), Version: 1},
+		},
+		{
+			name:        "null description",
+			contentType: mergePatch,
+			body:        `{"description":null}`,
+			status:      http.StatusOK,
+			want:        &models.UpdateRequest{Description: models.OptionalString{Set: true}},
+		},
+		{
+			name:        "null name",
+			contentType: mergePatch,
+			body:        `{"name":null}`,
+			status:      http.StatusOK,
+			want:        &models.UpdateRequest{Name: models.OptionalString{Set: true}},
+		},
+		{
+			name:        "blank name",
+			contentType: mergePatch,
+			body:        `{"name":" "}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "negative version",
+			contentType: mergePatch,
+			body:        `{"version":-1}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "malformed",
+			contentType: mergePatch,
+			body:        `{"name":`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "unsupported media type",
+			contentType: unknownContent,
+			body:        `{"name":"renamed"}`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+	})
+}
+
+// TestUpdateGoodRejectsEmptyName runs the request through the real service:
+// removing the name is valid JSON Patch but the column can't be null, so the
+// service turns it down before reaching storage.
+func TestUpdateGoodRejectsEmptyName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := services.NewGoodService(log, nil, nil, nil, config.Suggest{})
+
+	router := handlers.NewGoodHandler(log, service, nil, nil, nil, config.Idempotency{}, nil, config.Feed{}, nil, nil).InitRoutes()
+
+	for _, tc := range []struct{ contentType, body string }{
+		{jsonPatch, `[{"op":"remove","path":"/name"}]`},
+		{mergePatch, `{"name":null}`},
+		{mergePatch, `{"name":""}`},
+	} {
+		w := servePatch(router, tc.contentType, tc.body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: status = %d, want %d", tc.contentType, tc.body, w.Code, http.StatusBadRequest)
+		}
+		if !strings.Contains(w.Body.String(), "name") {
+			t.Errorf("%s %s: body %s doesn't name the field", tc.contentType, tc.body, w.Body.String())
+		}
+	}
+}
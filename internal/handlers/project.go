@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type ProjectProvider interface {
+	GetRules(ctx context.Context, projectID int) (*models.ProjectRules, error)
+	UpdateRules(ctx context.Context, rules *models.ProjectRules) (*models.ProjectRules, error)
+}
+
+func (h *GoodHandler) GetProjectRules(c *gin.Context) {
+	const op = "handlers.GetProjectRules"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	output, err := h.projectProvider.GetRules(c, projectID)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *GoodHandler) UpdateProjectRules(c *gin.Context) {
+	const op = "handlers.UpdateProjectRules"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	var input models.ProjectRules
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.NewErrorResponse(c, log, validationError(err))
+		return
+	}
+
+	input.ProjectID = projectID
+
+	output, err := h.projectProvider.UpdateRules(c, &input)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,417 @@
+package handlers_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/IskanderSh/hezzl-task/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const missingGoodID = 404
+
+// fakeService records the ids the handlers pass on. Goods with missingGoodID
+// don't exist; the methods the tests don't call panic on the nil interface.
+type fakeService struct {
+	handlers.ServiceProvider
+
+	id        int
+	projectID int
+	listed    bool
+	purge     *models.PurgeRequest
+	update    *models.UpdateRequest
+	exported  []models.Good
+}
+
+func (s *fakeService) good(id, projectID int) (*models.Good, error) {
+	s.id, s.projectID = id, projectID
+	if id == missingGoodID {
+		return nil, services.ErrGoodNotFound
+	}
+
+	return &models.Good{ID: id, ProjectID: projectID, Name: "good", Version: 1}, nil
+}
+
+func (s *fakeService) GetGood(_ context.Context, projectID, id int) (*models.Good, error) {
+	return s.good(id, projectID)
+}
+
+func (s *fakeService) GetGoods(_ context.Context, projectID, limit, offset int, _ bool) (*models.ListGoodsResponse, error) {
+	s.projectID, s.listed = projectID, true
+
+	return &models.ListGoodsResponse{Meta: models.Meta{Limit: limit, Offset: offset}, Goods: []models.Good{}}, nil
+}
+
+func (s *fakeService) CreateGood(_ context.Context, req *models.CreateRequest) (*models.Good, error) {
+	return s.good(1, req.ProjectID)
+}
+
+func (s *fakeService) UpdateGood(_ context.Context, req *models.UpdateRequest) (*models.Good, error) {
+	s.update = req
+
+	good, err := s.good(req.ID, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if (req.TestName.Set && req.TestName.Value != good.Name) ||
+		(req.TestDescription.Set && req.TestDescription.Value != good.Description) {
+		return nil, services.ErrTestFailed
+	}
+
+	return good, nil
+}
+
+func (s *fakeService) DeleteGood(_ context.Context, req *models.DeleteRequest) (*models.DeleteResponse, error) {
+	good, err := s.good(req.ID, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DeleteResponse{ID: good.ID, ProjectID: good.ProjectID, Removed: true, Version: 2}, nil
+}
+
+func (s *fakeService) RestoreGood(_ context.Context, req *models.RestoreRequest) (*models.Good, error) {
+	return s.good(req.ID, req.ProjectID)
+}
+
+func (s *fakeService) ReprioritizeGood(_ context.Context, req *models.ReprioritizeRequest) (*models.ReprioritizeResponse, error) {
+	good, err := s.good(req.ID, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReprioritizeResponse{Priorities: []models.Priorities{{ID: good.ID, Priority: req.NewPriority}}}, nil
+}
+
+func (s *fakeService) PurgeGoods(_ context.Context, req *models.PurgeRequest) (*models.PurgeResponse, error) {
+	s.projectID = req.ProjectID
+	s.purge = req
+
+	return &models.PurgeResponse{}, nil
+}
+
+func newRouter(t *testing.T) (*gin.Engine, *fakeService) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := &fakeService{}
+
+	handler := handlers.NewGoodHandler(log, service, nil, nil, nil, config.Idempotency{}, nil, config.Feed{}, nil, nil)
+
+	return handler.InitRoutes(), service
+}
+
+func serve(router *gin.Engine, method, target, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+// routePair is the same operation on the /v1 and on the legacy route, with
+// the ids in the path and in the query string respectively.
+type routePair struct {
+	name   string
+	method string
+	v1     string
+	legacy string
+	body   string
+}
+
+var goodRoutes = []routePair{
+	{
+		name:   "update",
+		method: http.MethodPatch,
+		v1:     "/v1/projects/%[1]s/goods/%[2]s",
+		legacy: "/good/update?projectId=%[1]s&id=%[2]s",
+		body:   `{"name":"renamed"}`,
+	},
+	{
+		name:   "delete",
+		method: http.MethodDelete,
+		v1:     "/v1/projects/%[1]s/goods/%[2]s",
+		legacy: "/good/delete?projectId=%[1]s&id=%[2]s",
+	},
+	{
+		name:   "reprioritize",
+		method: http.MethodPatch,
+		v1:     "/v1/projects/%[1]s/goods/%[2]s/priority",
+		legacy: "/good/reprioritize?projectId=%[1]s&id=%[2]s",
+		body:   `{"newPriority":3}`,
+	},
+	{
+		name:   "restore",
+		method: http.MethodPost,
+		v1:     "/v1/projects/%[1]s/goods/%[2]s/restore",
+		legacy: "/good/restore?projectId=%[1]s&id=%[2]s",
+	},
+}
+
+func target(format, projectID, id string) string {
+	return strings.NewReplacer("%[1]s", projectID, "%[2]s", id).Replace(format)
+}
+
+func TestGoodRoutesParity(t *testing.T) {
+	for _, route := range goodRoutes {
+		t.Run(route.name, func(t *testing.T) {
+			router, service := newRouter(t)
+
+			v1 := serve(router, route.method, target(route.v1, "7", "12"), route.body)
+			if v1.Code != http.StatusOK {
+				t.Fatalf("v1: status %d, body %s", v1.Code, v1.Body)
+			}
+			if service.id != 12 || service.projectID != 7 {
+				t.Fatalf("v1: got id %d in project %d, want 12 in 7", service.id, service.projectID)
+			}
+
+			*service = fakeService{}
+
+			legacy := serve(router, route.method, target(route.legacy, "7", "12"), route.body)
+			if legacy.Code != http.StatusOK {
+				t.Fatalf("legacy: status %d, body %s", legacy.Code, legacy.Body)
+			}
+			if service.id != 12 || service.projectID != 7 {
+				t.Fatalf("legacy: got id %d in project %d, want 12 in 7", service.id, service.projectID)
+			}
+
+			if v1.Body.String() != legacy.Body.String() {
+				t.Errorf("responses differ:\nv1:     %s\nlegacy: %s", v1.Body, legacy.Body)
+			}
+			if v1.Header().Get("ETag") != legacy.Header().Get("ETag") {
+				t.Errorf("etags differ: %q and %q", v1.Header().Get("ETag"), legacy.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestGoodRoutesBadIDs(t *testing.T) {
+	ids := []struct {
+		name      string
+		projectID string
+		id        string
+	}{
+		{name: "non-numeric id", projectID: "7", id: "abc"},
+		{name: "zero id", projectID: "7", id: "0"},
+		{name: "negative id", projectID: "7", id: "-1"},
+		{name: "non-numeric project", projectID: "abc", id: "12"},
+		{name: "zero project", projectID: "0", id: "12"},
+	}
+
+	for _, route := range goodRoutes {
+		for _, ids := range ids {
+			t.Run(route.name+"/"+ids.name, func(t *testing.T) {
+				router, service := newRouter(t)
+
+				for _, format := range []string{route.v1, route.legacy} {
+					w := serve(router, route.method, target(format, ids.projectID, ids.id), route.body)
+					if w.Code != http.StatusBadRequest {
+						t.Errorf("%s: status %d, want 400, body %s", format, w.Code, w.Body)
+					}
+				}
+
+				if service.id != 0 || service.projectID != 0 {
+					t.Errorf("service was called with id %d in project %d", service.id, service.projectID)
+				}
+			})
+		}
+	}
+}
+
+func TestLegacyRoutesMissingIDs(t *testing.T) {
+	for _, route := range goodRoutes {
+		t.Run(route.name, func(t *testing.T) {
+			router, _ := newRouter(t)
+
+			path, _, _ := strings.Cut(route.legacy, "?")
+
+			for _, query := range []string{"", "?id=12", "?projectId=7"} {
+				w := serve(router, route.method, path+query, route.body)
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("%s%s: status %d, want 400", path, query, w.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestGoodRoutesNotFound(t *testing.T) {
+	for _, route := range goodRoutes {
+		t.Run(route.name, func(t *testing.T) {
+			router, _ := newRouter(t)
+
+			for _, format := range []string{route.v1, route.legacy} {
+				w := serve(router, route.method, target(format, "7", "404"), route.body)
+				if w.Code != http.StatusNotFound {
+					t.Errorf("%s: status %d, want 404", format, w.Code)
+				}
+				if !strings.Contains(w.Body.String(), services.ErrGoodNotFound.Code) {
+					t.Errorf("%s: body %s has no %s code", format, w.Body, services.ErrGoodNotFound.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownRoutes(t *testing.T) {
+	router, _ := newRouter(t)
+
+	for _, path := range []string{"/v1/projects/7/goods/12/unknown", "/v1/unknown", "/good/unknown"} {
+		w := serve(router, http.MethodGet, path, "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", path, w.Code)
+		}
+	}
+}
+
+func TestCreateGoodParity(t *testing.T) {
+	router, service := newRouter(t)
+
+	v1 := serve(router, http.MethodPost, "/v1/projects/7/goods", `{"name":"good"}`)
+	if v1.Code != http.StatusOK || service.projectID != 7 {
+		t.Fatalf("v1: status %d, project %d", v1.Code, service.projectID)
+	}
+
+	*service = fakeService{}
+
+	legacy := serve(router, http.MethodPost, "/good/create?projectId=7", `{"name":"good"}`)
+	if legacy.Code != http.StatusOK || service.projectID != 7 {
+		t.Fatalf("legacy: status %d, project %d", legacy.Code, service.projectID)
+	}
+
+	if v1.Body.String() != legacy.Body.String() {
+		t.Errorf("responses differ:\nv1:     %s\nlegacy: %s", v1.Body, legacy.Body)
+	}
+
+	*service = fakeService{}
+
+	// the baseline route mobile clients still call
+	path := serve(router, http.MethodPost, "/good/create/7", `{"name":"good"}`)
+	if path.Code != http.StatusOK || service.projectID != 7 {
+		t.Fatalf("legacy path: status %d, project %d", path.Code, service.projectID)
+	}
+
+	if v1.Body.String() != path.Body.String() {
+		t.Errorf("responses differ:\nv1:          %s\nlegacy path: %s", v1.Body, path.Body)
+	}
+
+	for _, target := range []string{"/good/create?projectId=x", "/good/create/x", "/good/create/0"} {
+		if w := serve(router, http.MethodPost, target, `{"name":"good"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, w.Code)
+		}
+	}
+}
+
+func TestGetGood(t *testing.T) {
+	router, service := newRouter(t)
+
+	w := serve(router, http.MethodGet, "/v1/projects/7/goods/12", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if service.id != 12 || service.projectID != 7 {
+		t.Errorf("got id %d in project %d, want 12 in 7", service.id, service.projectID)
+	}
+	if w.Header().Get("ETag") != `"1"` {
+		t.Errorf("etag %q, want \"1\"", w.Header().Get("ETag"))
+	}
+
+	if w := serve(router, http.MethodGet, "/v1/projects/7/goods/404", ""); w.Code != http.StatusNotFound {
+		t.Errorf("missing good: status %d, want 404", w.Code)
+	}
+
+	for _, target := range []string{"/v1/projects/7/goods/abc", "/v1/projects/0/goods/12"} {
+		if w := serve(router, http.MethodGet, target, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, w.Code)
+		}
+	}
+}
+
+func TestListGoods(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		status    int
+		projectID int
+	}{
+		{name: "v1", target: "/v1/projects/7/goods", status: http.StatusOK, projectID: 7},
+		{name: "legacy", target: "/goods/list", status: http.StatusOK},
+		{name: "legacy with project", target: "/goods/list?projectId=7", status: http.StatusOK, projectID: 7},
+		{name: "v1 bad project", target: "/v1/projects/x/goods", status: http.StatusBadRequest},
+		{name: "legacy bad project", target: "/goods/list?projectId=0", status: http.StatusBadRequest},
+		{name: "unscoped v1", target: "/v1/goods", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, service := newRouter(t)
+
+			w := serve(router, http.MethodGet, tt.target, "")
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.status == http.StatusOK && (!service.listed || service.projectID != tt.projectID) {
+				t.Errorf("listed %t in project %d, want project %d", service.listed, service.projectID, tt.projectID)
+			}
+		})
+	}
+}
+
+func TestPurgeGoods(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		status int
+	}{
+		{name: "v1", target: "/v1/projects/7/goods/removed", body: `{"days":30}`, status: http.StatusOK},
+		{name: "legacy", target: "/good/purge?projectId=7", body: `{"days":30}`, status: http.StatusOK},
+		{name: "v1 without body", target: "/v1/projects/7/goods/removed", status: http.StatusBadRequest},
+		{name: "legacy without body", target: "/good/purge?projectId=7", status: http.StatusBadRequest},
+		{name: "zero days", target: "/v1/projects/7/goods/removed", body: `{"days":0}`, status: http.StatusBadRequest},
+		{name: "legacy without project", target: "/good/purge", body: `{"days":30}`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, service := newRouter(t)
+
+			w := serve(router, http.MethodDelete, tt.target, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+
+			if tt.status != http.StatusOK {
+				if service.purge != nil {
+					t.Errorf("service was called with %+v", service.purge)
+				}
+				return
+			}
+
+			if service.purge.ProjectID != 7 || service.purge.Days != 30 {
+				t.Errorf("got %+v, want project 7 and 30 days", service.purge)
+			}
+		})
+	}
+}
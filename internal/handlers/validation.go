@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// init teaches the gin validator about the request models: fields are reported
+// by their json names, OptionalString is validated as the string it holds and
+// notblank rejects whitespace-only values.
+func init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	validate.RegisterCustomTypeFunc(func(value reflect.Value) any {
+		optional := value.Interface().(models.OptionalString)
+		if !optional.Valid {
+			return nil
+		}
+		return optional.Value
+	}, models.OptionalString{})
+
+	_ = validate.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+}
+
+// Validate checks a request model against its binding tags, for transports
+// that don't bind requests through gin.
+func Validate(input any) error {
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		return validationError(err)
+	}
+
+	return nil
+}
+
+// validationError turns binding errors into errInvalidBody with a detail for
+// every field that failed validation.
+func validationError(err error) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return errInvalidBody
+	}
+
+	details := make([]apperr.Detail, 0, len(errs))
+	for _, fieldErr := range errs {
+		field := fieldErr.Namespace()
+		if _, rest, ok := strings.Cut(field, "."); ok {
+			field = rest
+		}
+
+		details = append(details, validationDetail(field, fieldErr))
+	}
+
+	return errInvalidBody.WithDetails(details...)
+}
+
+func validationDetail(field string, err validator.FieldError) apperr.Detail {
+	detail := apperr.Detail{Field: field}
+
+	switch err.Tag() {
+	case "required":
+		detail.Code = apperr.CodeRequired
+	case "notblank":
+		detail.Code = apperr.CodeNotBlank
+	case "max":
+		detail.Code, detail.Params = apperr.CodeMax, []any{err.Param()}
+		if err.Kind() == reflect.String {
+			detail.Code = apperr.CodeMaxLength
+		}
+	case "min":
+		detail.Code, detail.Params = apperr.CodeMin, []any{err.Param()}
+		if err.Kind() == reflect.String {
+			detail.Code = apperr.CodeMinLength
+		}
+	case "http_url":
+		detail.Code = apperr.CodeURL
+	case "oneof":
+		detail.Code, detail.Params = apperr.CodeUnknownValue, []any{err.Value()}
+	default:
+		detail.Code, detail.Params = apperr.CodeFailed, []any{err.Tag()}
+	}
+
+	return detail
+}
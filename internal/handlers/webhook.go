@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookProvider interface {
+	CreateWebhook(ctx context.Context, req *models.WebhookRequest) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context, projectID int) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, projectID, id int) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, req *models.WebhookRequest) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, projectID, id int) error
+	ListDeliveries(ctx context.Context, projectID, webhookID, limit, offset int) ([]models.WebhookDelivery, error)
+}
+
+func (h *GoodHandler) ListWebhooks(c *gin.Context) {
+	const op = "handlers.ListWebhooks"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	output, err := h.webhookProvider.ListWebhooks(c, projectID)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// CreateWebhook is the only response that carries the secret, unless it is
+// changed later with UpdateWebhook.
+func (h *GoodHandler) CreateWebhook(c *gin.Context) {
+	const op = "handlers.CreateWebhook"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	var input models.WebhookRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.NewErrorResponse(c, log, validationError(err))
+		return
+	}
+
+	input.ProjectID = projectID
+
+	output, err := h.webhookProvider.CreateWebhook(c, &input)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *GoodHandler) GetWebhook(c *gin.Context) {
+	const op = "handlers.GetWebhook"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, id, err := webhookIDs(c)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	output, err := h.webhookProvider.GetWebhook(c, projectID, id)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *GoodHandler) UpdateWebhook(c *gin.Context) {
+	const op = "handlers.UpdateWebhook"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, id, err := webhookIDs(c)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	var input models.WebhookRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.NewErrorResponse(c, log, validationError(err))
+		return
+	}
+
+	input.ID, input.ProjectID = id, projectID
+
+	output, err := h.webhookProvider.UpdateWebhook(c, &input)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *GoodHandler) DeleteWebhook(c *gin.Context) {
+	const op = "handlers.DeleteWebhook"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, id, err := webhookIDs(c)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	if err := h.webhookProvider.DeleteWebhook(c, projectID, id); err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries returns the delivery log of the webhook, newest first.
+func (h *GoodHandler) ListWebhookDeliveries(c *gin.Context) {
+	const op = "handlers.ListWebhookDeliveries"
+
+	log := h.log.With(slog.String("op", op))
+
+	projectID, id, err := webhookIDs(c)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	limit, err := getQueryInt(c, limitCtx, defaultLimit)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	offset, err := getQueryInt(c, offsetCtx, defaultSearchOffset)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	output, err := h.webhookProvider.ListDeliveries(c, projectID, id, limit, offset)
+	if err != nil {
+		response.NewErrorResponse(c, log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func webhookIDs(c *gin.Context) (int, int, error) {
+	projectID, err := getID(c, projectCtx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	id, err := getID(c, idCtx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return projectID, id, nil
+}
@@ -0,0 +1,132 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOpen = errors.New("circuit breaker is open")
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+)
+
+func (s State) String() string {
+	if s == Open {
+		return "open"
+	}
+
+	return "closed"
+}
+
+// Breaker opens after threshold consecutive failures and then rejects every
+// call until Reset: the owner probes the resource and decides when it has
+// recovered.
+type Breaker struct {
+	threshold int
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trips    uint64
+	rejected uint64
+}
+
+// Stats is a snapshot of the breaker for health checks and metrics.
+type Stats struct {
+	State    string     `json:"state"`
+	Failures int        `json:"consecutive_failures"`
+	Trips    uint64     `json:"trips"`
+	Rejected uint64     `json:"rejected"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+func New(threshold int) *Breaker {
+	return &Breaker{threshold: threshold}
+}
+
+// Allow reports whether a call may go through.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		b.rejected++
+		return false
+	}
+
+	return true
+}
+
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+}
+
+// Failure records a failed call and reports whether it opened the breaker.
+func (b *Breaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		return false
+	}
+
+	b.failures++
+	if b.failures < b.threshold {
+		return false
+	}
+
+	b.state = Open
+	b.openedAt = time.Now()
+	b.trips++
+
+	return true
+}
+
+// Reset closes the breaker and reports whether it was open.
+func (b *Breaker) Reset() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasOpen := b.state == Open
+	b.state = Closed
+	b.failures = 0
+
+	return wasOpen
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *Breaker) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := Stats{
+		State:    b.state.String(),
+		Failures: b.failures,
+		Trips:    b.trips,
+		Rejected: b.rejected,
+	}
+
+	if b.state == Open {
+		openedAt := b.openedAt
+		stats.OpenedAt = &openedAt
+	}
+
+	return stats
+}
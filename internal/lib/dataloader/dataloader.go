@@ -0,0 +1,104 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFunc fetches values for all keys at once. Keys missing from the result
+// resolve to the zero value.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader batches and caches lookups for the lifetime of one request.
+//
+// Instead of waiting for a time window, callers announce the keys they are
+// going to need with Want (e.g. a list resolver announces the ids of all its
+// items), and the first Load fetches every announced key in one batch.
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	wanted  map[K]struct{}
+	results map[K]*result[V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func New[K comparable, V any](fetch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:   fetch,
+		wanted:  make(map[K]struct{}),
+		results: make(map[K]*result[V]),
+	}
+}
+
+// Want adds keys to the next batch. Keys that are already loaded or pending
+// are skipped.
+func (l *Loader[K, V]) Want(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.want(keys)
+}
+
+// Load returns the value for key, fetching it together with all wanted keys
+// when it isn't loaded yet.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+
+	if res, ok := l.results[key]; ok {
+		l.mu.Unlock()
+		return res.wait(ctx)
+	}
+
+	l.want([]K{key})
+
+	keys := l.pending
+	batch := make(map[K]*result[V], len(keys))
+	for _, k := range keys {
+		res := &result[V]{done: make(chan struct{})}
+		batch[k] = res
+		l.results[k] = res
+		delete(l.wanted, k)
+	}
+	l.pending = nil
+
+	l.mu.Unlock()
+
+	values, err := l.fetch(ctx, keys)
+	for k, res := range batch {
+		res.value, res.err = values[k], err
+		close(res.done)
+	}
+
+	return batch[key].value, err
+}
+
+func (l *Loader[K, V]) want(keys []K) {
+	for _, key := range keys {
+		if _, ok := l.results[key]; ok {
+			continue
+		}
+		if _, ok := l.wanted[key]; ok {
+			continue
+		}
+
+		l.wanted[key] = struct{}{}
+		l.pending = append(l.pending, key)
+	}
+}
+
+func (r *result[V]) wait(ctx context.Context) (V, error) {
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
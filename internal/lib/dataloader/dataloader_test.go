@@ -0,0 +1,154 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const loadKeys = 50
+
+func TestLoaderBatchesWantedKeys(t *testing.T) {
+	var calls atomic.Int32
+	var fetched []int
+
+	loader := New(func(_ context.Context, keys []int) (map[int]string, error) {
+		calls.Add(1)
+		fetched = append(fetched, keys...)
+
+		values := make(map[int]string, len(keys))
+		for _, key := range keys {
+			if key%10 != 0 {
+				values[key] = "value"
+			}
+		}
+		return values, nil
+	})
+
+	// A list resolver announces every child up front, then each child loads
+	// its own key, the way nested GraphQL fields resolve.
+	keys := make([]int, loadKeys)
+	for i := range keys {
+		keys[i] = i + 1
+	}
+	loader.Want(keys...)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, loadKeys)
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+
+			value, err := loader.Load(context.Background(), key)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			want := "value"
+			if key%10 == 0 {
+				want = ""
+			}
+			if value != want {
+				errs <- errors.New("unexpected value for key")
+			}
+		}(key)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("batch calls = %d, want 1", got)
+	}
+	if len(fetched) != loadKeys {
+		t.Fatalf("fetched %d keys, want %d", len(fetched), loadKeys)
+	}
+
+	if _, err := loader.Load(context.Background(), 1); err != nil {
+		t.Fatalf("cached load: %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("batch calls after cached load = %d, want 1", got)
+	}
+}
+
+func TestLoaderErrorReachesEveryWaiter(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var calls atomic.Int32
+	loader := New(func(_ context.Context, keys []int) (map[int]string, error) {
+		calls.Add(1)
+		close(started)
+		<-release
+		return nil, errFetch
+	})
+
+	loader.Want(1, 2, 3)
+
+	results := make(chan error, 4)
+	go func() {
+		_, err := loader.Load(context.Background(), 1)
+		results <- err
+	}()
+
+	// The other keys join the batch already in flight instead of starting one.
+	<-started
+	for _, key := range []int{1, 2, 3} {
+		go func(key int) {
+			_, err := loader.Load(context.Background(), key)
+			results <- err
+		}(key)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 4; i++ {
+		select {
+		case err := <-results:
+			if !errors.Is(err, errFetch) {
+				t.Errorf("err = %v, want %v", err, errFetch)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("waiter didn't get the fetch error")
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("batch calls = %d, want 1", got)
+	}
+}
+
+func TestLoaderWaitHonoursContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	loader := New(func(_ context.Context, keys []int) (map[int]string, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+
+	loader.Want(1, 2)
+	go loader.Load(context.Background(), 1)
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := loader.Load(ctx, 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,116 @@
+package apperr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind classifies an error independently of the transport; handlers map it
+// to a status code.
+type Kind int
+
+const (
+	Internal Kind = iota
+	Validation
+	NotFound
+	Conflict
+	PreconditionFailed
+	UnsupportedMediaType
+	Unprocessable
+	Unavailable
+)
+
+// Error is a domain error with a stable code (e.g. errors.good.NotFound) that
+// clients can rely on, unlike the message.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Details []Detail
+}
+
+// Detail points at the part of the request that caused the error. Code is a
+// message key; Message is filled in the caller's language when responding.
+type Detail struct {
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	Params  []any  `json:"-"`
+}
+
+// Detail codes shared by request validation.
+const (
+	CodeRequired        = "errors.validation.Required"
+	CodeNotBlank        = "errors.validation.NotBlank"
+	CodeNotNull         = "errors.validation.NotNull"
+	CodeMaxLength       = "errors.validation.MaxLength"
+	CodeMinLength       = "errors.validation.MinLength"
+	CodeMax             = "errors.validation.Max"
+	CodeMin             = "errors.validation.Min"
+	CodeFailed          = "errors.validation.Failed"
+	CodeType            = "errors.validation.Type"
+	CodeInteger         = "errors.validation.Integer"
+	CodeBoolean         = "errors.validation.Boolean"
+	CodeTimestamp       = "errors.validation.Timestamp"
+	CodeETag            = "errors.validation.ETag"
+	CodeMapping         = "errors.validation.Mapping"
+	CodeUnique          = "errors.validation.Unique"
+	CodeUnknownValue    = "errors.validation.UnknownValue"
+	CodeReadOnly        = "errors.validation.ReadOnly"
+	CodeOperationsCount = "errors.validation.OperationsCount"
+	CodeCSVHeader       = "errors.validation.CSVHeader"
+	CodeURL             = "errors.validation.URL"
+	CodePublicAddress   = "errors.validation.PublicAddress"
+	CodeSyncToken       = "errors.validation.SyncToken"
+)
+
+// Errors shared by every transport.
+var (
+	ErrInternal     = New(Internal, "errors.Internal", "internal error")
+	ErrInvalidParam = New(Validation, "errors.request.InvalidParam", "invalid request parameter")
+	ErrInvalidBody  = New(Validation, "errors.request.InvalidBody", "invalid input body")
+)
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if len(e.Details) == 0 {
+		return e.Message
+	}
+
+	details := make([]string, 0, len(e.Details))
+	for _, detail := range e.Details {
+		text := detail.Code
+		if len(detail.Params) > 0 {
+			text += fmt.Sprint(detail.Params)
+		}
+		if detail.Field != "" {
+			text = detail.Field + ": " + text
+		}
+		details = append(details, text)
+	}
+
+	return e.Message + ": " + strings.Join(details, "; ")
+}
+
+// Is reports errors with the same code as equal, so a copy made by
+// WithDetails still matches its sentinel in errors.Is.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetails returns a copy of the error with the details appended.
+func (e *Error) WithDetails(details ...Detail) *Error {
+	res := *e
+	res.Details = append(append([]Detail(nil), e.Details...), details...)
+
+	return &res
+}
+
+// WithField is a shorthand for WithDetails with a single field.
+func (e *Error) WithField(field, code string, params ...any) *Error {
+	return e.WithDetails(Detail{Field: field, Code: code, Params: params})
+}
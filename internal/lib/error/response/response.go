@@ -1,16 +1,106 @@
 package response
 
 import (
+	"errors"
 	"log/slog"
+	"net/http"
 
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/i18n"
 	"github.com/gin-gonic/gin"
 )
 
-type errorResponse struct {
-	Message string `json:"message"`
+// RequestIDKey is the gin context key the request id is stored under.
+const RequestIDKey = "request_id"
+
+const (
+	acceptLanguageHeader  = "Accept-Language"
+	contentLanguageHeader = "Content-Language"
+)
+
+// ErrorResponse is the envelope of every error the API returns.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code      string          `json:"code"`
+	Message   string          `json:"message"`
+	Details   []apperr.Detail `json:"details,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// NewErrorResponse aborts the request with the error envelope. Errors that are
+// not an *apperr.Error are reported as internal without exposing their text.
+// Codes are translated to the language asked for in Accept-Language.
+func NewErrorResponse(c *gin.Context, log *slog.Logger, err error) {
+	appErr := apperr.ErrInternal
+	errors.As(err, &appErr)
+
+	statusCode := Status(appErr.Kind)
+	requestID := c.GetString(RequestIDKey)
+
+	log = log.With(slog.String("code", appErr.Code), slog.String("request_id", requestID))
+	if statusCode >= http.StatusInternalServerError {
+		log.Error(err.Error())
+	} else {
+		log.Info(err.Error())
+	}
+
+	lang := i18n.Match(c.GetHeader(acceptLanguageHeader))
+	c.Header(contentLanguageHeader, lang)
+
+	c.AbortWithStatusJSON(statusCode, ErrorResponse{Error: ErrorBody{
+		Code:      appErr.Code,
+		Message:   Translate(lang, appErr),
+		Details:   TranslateDetails(lang, appErr.Details),
+		RequestID: requestID,
+	}})
+}
+
+// Translate returns the error message in lang, or the built-in one when the
+// catalogue has no such key.
+func Translate(lang string, err *apperr.Error) string {
+	if message, ok := i18n.Translate(lang, err.Code); ok {
+		return message
+	}
+
+	return err.Message
+}
+
+func TranslateDetails(lang string, details []apperr.Detail) []apperr.Detail {
+	if len(details) == 0 {
+		return nil
+	}
+
+	res := make([]apperr.Detail, len(details))
+	for i, detail := range details {
+		res[i] = detail
+		if message, ok := i18n.Translate(lang, detail.Code, detail.Params...); ok {
+			res[i].Message = message
+		}
+	}
+
+	return res
 }
 
-func NewErrorResponse(c *gin.Context, log *slog.Logger, statusCode int, message string) {
-	log.Error(message)
-	c.AbortWithStatusJSON(statusCode, errorResponse{Message: message})
+func Status(kind apperr.Kind) int {
+	switch kind {
+	case apperr.Validation:
+		return http.StatusBadRequest
+	case apperr.NotFound:
+		return http.StatusNotFound
+	case apperr.Conflict:
+		return http.StatusConflict
+	case apperr.PreconditionFailed:
+		return http.StatusPreconditionFailed
+	case apperr.UnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case apperr.Unprocessable:
+		return http.StatusUnprocessableEntity
+	case apperr.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -0,0 +1,101 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLanguage is used when Accept-Language matches nothing and for keys
+// missing in the requested language.
+const DefaultLanguage = "en"
+
+// locales holds one <language>.json file per language, mapping message keys
+// (e.g. errors.good.NotFound) to fmt templates. A new language only needs a file.
+//
+//go:embed locales/*.json
+var locales embed.FS
+
+type catalogue struct {
+	languages []string
+	messages  map[string]map[string]string
+	matcher   language.Matcher
+}
+
+var defaultCatalogue = mustLoad()
+
+func mustLoad() *catalogue {
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	c := &catalogue{
+		languages: []string{DefaultLanguage},
+		messages:  make(map[string]map[string]string, len(files)),
+	}
+
+	for _, file := range files {
+		data, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		lang := strings.TrimSuffix(file.Name(), path.Ext(file.Name()))
+
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid locale %s: %s", file.Name(), err.Error()))
+		}
+
+		c.messages[lang] = messages
+		if lang != DefaultLanguage {
+			c.languages = append(c.languages, lang)
+		}
+	}
+
+	tags := make([]language.Tag, len(c.languages))
+	for i, lang := range c.languages {
+		tags[i] = language.MustParse(lang)
+	}
+	c.matcher = language.NewMatcher(tags)
+
+	return c
+}
+
+// Match picks the best supported language for an Accept-Language header value.
+func Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLanguage
+	}
+
+	_, index, confidence := defaultCatalogue.matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLanguage
+	}
+
+	return defaultCatalogue.languages[index]
+}
+
+// Translate formats the message for key in lang, falling back to the default
+// language. It reports false when no language has the key.
+func Translate(lang, key string, params ...any) (string, bool) {
+	template, ok := defaultCatalogue.messages[lang][key]
+	if !ok {
+		template, ok = defaultCatalogue.messages[DefaultLanguage][key]
+	}
+	if !ok {
+		return "", false
+	}
+
+	if len(params) == 0 {
+		return template, true
+	}
+
+	return fmt.Sprintf(template, params...), true
+}
@@ -0,0 +1,54 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Policy describes how an operation is retried. The delay doubles after every
+// failed attempt up to MaxDelay.
+type Policy struct {
+	// Attempts limits the number of calls, zero retries until ctx is done.
+	Attempts int
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+// Do calls fn until it succeeds, the attempts run out or ctx is done, and
+// returns the last error of fn.
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if policy.Attempts > 0 && attempt >= policy.Attempts {
+			return err
+		}
+
+		timer := time.NewTimer(policy.Backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// Backoff returns the delay after the given failed attempt. Half of it is
+// randomised, so that many clients retrying at once don't line up.
+func (p Policy) Backoff(attempt int) time.Duration {
+	delay := p.Delay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	half := delay / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
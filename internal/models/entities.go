@@ -1,39 +1,308 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Good struct {
-	ID          int           `db:"id"`
-	ProjectID   int           `db:"project_id"`
-	Name        string        `db:"name"`
-	Description string        `db:"description"`
-	Priority    int           `db:"priority"`
-	Removed     bool          `db:"removed"`
-	CreatedAt   time.Duration `db:"created_at"`
+	ID          int        `db:"id"`
+	ProjectID   int        `db:"project_id"`
+	Name        string     `db:"name"`
+	Description string     `db:"description"`
+	Priority    int        `db:"priority"`
+	Removed     bool       `db:"removed"`
+	RemovedAt   *time.Time `db:"removed_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+	Version     int        `db:"version"`
+	SKU         string     `db:"sku"`
 }
 
 type CreateRequest struct {
 	ProjectID int    `json:"project_id,omitempty" db:"project_id"`
-	Name      string `json:"name" db:"name"`
+	Name      string `json:"name" db:"name" binding:"required,notblank,max=255"`
 	Priority  int    `json:"priority_id,omitempty" db:"priority"`
 }
 
+// UpdateRequest follows JSON Merge Patch semantics: omitted fields stay
+// untouched and explicit nulls clear them.
 type UpdateRequest struct {
-	ID          int    `json:"id,omitempty" db:"id"`
-	ProjectID   int    `json:"project_id,omitempty" db:"project_id"`
-	Name        string `json:"name" db:"name"`
-	Description string `json:"description" db:"description"`
+	ID          int            `json:"id,omitempty" db:"id"`
+	ProjectID   int            `json:"project_id,omitempty" db:"project_id"`
+	Name        OptionalString `json:"name" db:"name" binding:"omitempty,notblank,max=255"`
+	Description OptionalString `json:"description" db:"description"`
+	Version     int            `json:"version,omitempty" db:"version" binding:"min=0"`
+
+	// TestName and TestDescription come from JSON Patch "test" operations
+	// and must match the stored good for the update to apply.
+	TestName        OptionalString `json:"-" db:"-"`
+	TestDescription OptionalString `json:"-" db:"-"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
 }
 
 type DeleteRequest struct {
 	ID        int `json:"id" db:"id"`
 	ProjectID int `json:"project_id" db:"project_id"`
+	Version   int `json:"version,omitempty" db:"version" binding:"min=0"`
 }
 
 type DeleteResponse struct {
 	ID        int  `json:"id" db:"id"`
 	ProjectID int  `json:"project_id" db:"project_id"`
 	Removed   bool `json:"removed" db:"removed"`
+	Version   int  `json:"version" db:"version"`
+}
+
+type RestoreRequest struct {
+	ID        int `json:"id" db:"id"`
+	ProjectID int `json:"project_id" db:"project_id"`
+}
+
+// PurgeRequest is scoped to one project. Days is required, so that an empty
+// request can't empty the whole trash at once.
+type PurgeRequest struct {
+	ProjectID     int       `json:"-"`
+	Days          int       `json:"days" binding:"required,min=1"`
+	RemovedBefore time.Time `json:"-"`
+	Limit         int       `json:"-"`
+}
+
+type PurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+type BatchRequest struct {
+	ProjectID  int              `json:"-"`
+	Mode       string           `json:"mode"`
+	Operations []BatchOperation `json:"operations" binding:"dive"`
+}
+
+type BatchOperation struct {
+	Op          string         `json:"op"`
+	ID          int            `json:"id,omitempty"`
+	Name        OptionalString `json:"name" binding:"omitempty,notblank,max=255"`
+	Description OptionalString `json:"description"`
+	Version     int            `json:"version,omitempty" binding:"min=0"`
+}
+
+type BatchResponse struct {
+	Mode      string        `json:"mode"`
+	Applied   bool          `json:"applied"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+type BatchResult struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	Good  *Good  `json:"good,omitempty"`
+	Error string `json:"error,omitempty"`
+	Err   error  `json:"-"`
+}
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+
+	ImportModeInsert = "insert"
+	ImportModeUpsert = "upsert"
+
+	ImportKeyName = "name"
+	ImportKeySKU  = "sku"
+)
+
+type ImportRequest struct {
+	ProjectID int
+	Format    string
+	Mode      string
+	Key       string
+	DryRun    bool
+	// Mapping maps good fields (name, description, sku) to source column names.
+	Mapping map[string]string
+}
+
+type ImportRow struct {
+	Line        int    `db:"line"`
+	Name        string `db:"name"`
+	Description string `db:"description"`
+	SKU         string `db:"sku"`
+}
+
+// ImportRejection is a row that passed validation but conflicts with the
+// stored goods or the project rules on Field.
+type ImportRejection struct {
+	ImportRow
+	Field string `db:"-"`
+}
+
+// ImportResult carries the merged goods, so that their events are published
+// and the caches refreshed once the import is committed.
+type ImportResult struct {
+	Inserted []Good
+	Updated  []Good
+	Rejected []ImportRejection
+	// Rules are the project rules the rows were checked against.
+	Rules ProjectRules
+}
+
+type ImportReport struct {
+	Total           int           `json:"total"`
+	Valid           int           `json:"valid"`
+	Invalid         int           `json:"invalid"`
+	Inserted        int           `json:"inserted"`
+	Updated         int           `json:"updated"`
+	DryRun          bool          `json:"dry_run"`
+	Errors          []ImportError `json:"errors"`
+	ErrorsTruncated bool          `json:"errors_truncated"`
+}
+
+type ImportError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
+
+type ExportRequest struct {
+	ProjectID      int
+	Format         string
+	IncludeRemoved bool
+	Name           string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+}
+
+// ChangesRequest asks for the project's good changes since the token of the
+// previous sync. An empty token starts a full sync.
+type ChangesRequest struct {
+	ProjectID int
+	Since     string
+	Limit     int
+}
+
+// ChangesFilter selects the changes made by transactions in [FromXID, ToXID)
+// after AfterSeq. A zero ToXID is replaced with the current horizon.
+type ChangesFilter struct {
+	ProjectID int
+	FromXID   int64
+	ToXID     int64
+	AfterSeq  int64
+	Limit     int
+}
+
+// GoodChange is the latest state of a changed good. Purged goods only carry
+// their ids.
+type GoodChange struct {
+	Good
+	ChangeSeq int64 `db:"change_seq"`
+	Purged    bool  `db:"purged"`
+}
+
+type Tombstone struct {
+	ID        int `json:"id"`
+	ProjectID int `json:"project_id"`
+}
+
+// ChangesResponse is a page of changes: removed and purged goods come as
+// tombstones. Token is the since of the next request; with HasMore it should
+// be sent right away.
+type ChangesResponse struct {
+	Upserts    []Good      `json:"upserts"`
+	Tombstones []Tombstone `json:"tombstones"`
+	Token      string      `json:"token"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// ProjectRules are optional constraints a project puts on its goods. They are
+// checked on create and update; a zero MaxDescriptionLength means no limit.
+type ProjectRules struct {
+	ProjectID            int  `json:"project_id" db:"project_id"`
+	UniqueNames          bool `json:"unique_names" db:"unique_names"`
+	MaxDescriptionLength int  `json:"max_description_length" db:"max_description_length" binding:"min=0"`
+}
+
+type Project struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// GoodKey identifies a good, whose id is only unique within a project.
+type GoodKey struct {
+	ID        int
+	ProjectID int
+}
+
+// ProjectGoodsFilter selects a page of goods in each of the requested projects.
+type ProjectGoodsFilter struct {
+	Name           string
+	IncludeRemoved bool
+	Limit          int
+	Offset         int
+}
+
+type GoodsPage struct {
+	Total int
+	Goods []Good
+}
+
+// Webhook is a project's subscription to good events. An empty Events list
+// subscribes to all of them. The secret is only returned when it is set.
+type Webhook struct {
+	ID           int        `json:"id" db:"id"`
+	ProjectID    int        `json:"project_id" db:"project_id"`
+	URL          string     `json:"url" db:"url"`
+	Secret       string     `json:"secret,omitempty" db:"secret"`
+	Events       []string   `json:"events" db:"events"`
+	Enabled      bool       `json:"enabled" db:"enabled"`
+	FailureCount int        `json:"failure_count" db:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// WebhookRequest creates or replaces a webhook. A missing secret is generated
+// on create and kept on update; enabling a webhook resets its failures.
+type WebhookRequest struct {
+	ID        int      `json:"-"`
+	ProjectID int      `json:"-"`
+	URL       string   `json:"url" binding:"required,http_url,max=2048"`
+	Secret    string   `json:"secret" binding:"max=255"`
+	Events    []string `json:"events" binding:"dive,oneof=create update delete restore reprioritize purge"`
+	Enabled   *bool    `json:"enabled"`
+}
+
+// WebhookDelivery is one attempt to deliver an event to a webhook.
+type WebhookDelivery struct {
+	ID         int64     `json:"id" db:"id"`
+	WebhookID  int       `json:"webhook_id" db:"webhook_id"`
+	EventID    string    `json:"event_id" db:"event_id"`
+	Event      string    `json:"event" db:"event"`
+	Attempt    int       `json:"attempt" db:"attempt"`
+	Success    bool      `json:"success" db:"success"`
+	StatusCode *int      `json:"status_code,omitempty" db:"status_code"`
+	Error      *string   `json:"error,omitempty" db:"error"`
+	DurationMS int       `json:"duration_ms" db:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
 type ListGoodsResponse struct {
@@ -51,7 +320,8 @@ type Meta struct {
 type ReprioritizeRequest struct {
 	ID          int `db:"id"`
 	ProjectID   int `db:"project_id"`
-	NewPriority int `json:"newPriority"`
+	NewPriority int `json:"newPriority" binding:"min=0"`
+	Version     int `json:"version,omitempty" db:"version" binding:"min=0"`
 }
 
 type ReprioritizeResponse struct {
@@ -61,23 +331,157 @@ type ReprioritizeResponse struct {
 type Priorities struct {
 	ID       int `json:"id" db:"id"`
 	Priority int `json:"priority" db:"priority"`
+	Version  int `json:"version" db:"version"`
+}
+
+type SearchRequest struct {
+	ProjectID      int
+	Query          string
+	Limit          int
+	Offset         int
+	IncludeRemoved bool
+}
+
+type SearchResponse struct {
+	Meta    SearchMeta     `json:"meta"`
+	Results []SearchResult `json:"results"`
+}
+
+type SearchMeta struct {
+	Query  string `json:"query"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+type SearchResult struct {
+	ID                   int        `json:"id" db:"id"`
+	ProjectID            int        `json:"project_id" db:"project_id"`
+	Name                 string     `json:"name" db:"name"`
+	Description          string     `json:"description" db:"description"`
+	Priority             int        `json:"priority" db:"priority"`
+	Removed              bool       `json:"removed" db:"removed"`
+	RemovedAt            *time.Time `json:"removed_at,omitempty" db:"removed_at"`
+	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
+	Version              int        `json:"version" db:"version"`
+	SKU                  string     `json:"sku,omitempty" db:"sku"`
+	Rank                 float64    `json:"rank" db:"rank"`
+	NameHighlight        string     `json:"name_highlight" db:"name_highlight"`
+	DescriptionHighlight string     `json:"description_highlight" db:"description_highlight"`
+	Total                int        `json:"-" db:"total"`
+}
+
+type SuggestRequest struct {
+	ProjectID int
+	Query     string
+	Limit     int
+}
+
+type SuggestResponse struct {
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+type Suggestion struct {
+	ID    int     `json:"id" db:"id"`
+	Name  string  `json:"name" db:"name"`
+	Score float64 `json:"score" db:"score"`
+}
+
+// IdempotencyRecord is stored per Idempotency-Key. Until Completed is set the
+// original request is still being processed.
+type IdempotencyRecord struct {
+	Fingerprint string            `json:"fingerprint"`
+	Completed   bool              `json:"completed"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
 }
 
 type GoodCache struct {
+	ID          int
 	ProjectID   int
 	Name        string
 	Description string
 	Priority    int
 	Removed     bool
-	CreatedAt   time.Duration
+	RemovedAt   *time.Time
+	CreatedAt   time.Time
+	Version     int
+	SKU         string
+}
+
+func (g *GoodCache) MarshalBinary() ([]byte, error) {
+	return json.Marshal(g)
 }
 
 type GoodLog struct {
-	ID          int           `db:"Id"`
-	ProjectID   int           `db:"ProjectId"`
-	Name        string        `db:"Name"`
-	Description string        `db:"Description"`
-	Priority    int           `db:"Priority"`
-	Removed     bool          `db:"Removed"`
-	EventTime   time.Duration `db:"EventTime"`
+	ID          int       `db:"Id"`
+	ProjectID   int       `db:"ProjectId"`
+	Name        string    `db:"Name"`
+	Description string    `db:"Description"`
+	Priority    int       `db:"Priority"`
+	Removed     bool      `db:"Removed"`
+	EventTime   time.Time `db:"EventTime"`
+	Event       string    `db:"Event"`
+}
+
+const (
+	EventCreate       = "create"
+	EventUpdate       = "update"
+	EventDelete       = "delete"
+	EventRestore      = "restore"
+	EventReprioritize = "reprioritize"
+	EventPurge        = "purge"
+)
+
+// FeedEvent is a good change pushed to the stream subscribers of its project.
+type FeedEvent struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	EventTime time.Time `json:"event_time"`
+	Good      FeedGood  `json:"good"`
+}
+
+type FeedGood struct {
+	ID          int    `json:"id"`
+	ProjectID   int    `json:"project_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Priority    int    `json:"priority"`
+	Removed     bool   `json:"removed"`
+}
+
+// FeedSubscription receives the events of one project. Events is closed when
+// the subscriber falls behind; it should reconnect with the last event id.
+type FeedSubscription struct {
+	ProjectID int
+	// Replay holds the buffered events after the requested last event id.
+	Replay []FeedEvent
+	// Reset is set when the events after the last event id are no longer
+	// buffered, so the client has to reload the goods.
+	Reset  bool
+	Events <-chan FeedEvent
+}
+
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+
+	// ReadinessDegraded means only optional dependencies are down.
+	ReadinessReady       = "ready"
+	ReadinessDegraded    = "degraded"
+	ReadinessUnavailable = "unavailable"
+)
+
+// DependencyStatus is the result of one readiness check.
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	Optional  bool    `json:"optional"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Readiness struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
 }
@@ -0,0 +1,40 @@
+package models
+
+import "encoding/json"
+
+// OptionalString tells an omitted JSON field (Set is false) apart from an
+// explicit null (Set is true, Valid is false).
+type OptionalString struct {
+	Set   bool
+	Valid bool
+	Value string
+}
+
+func NewOptionalString(value string) OptionalString {
+	return OptionalString{Set: true, Valid: true, Value: value}
+}
+
+func (o *OptionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+
+	if string(data) == "null" {
+		o.Valid = false
+		o.Value = ""
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Valid = true
+
+	return nil
+}
+
+func (o OptionalString) MarshalJSON() ([]byte, error) {
+	if !o.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(o.Value)
+}
@@ -0,0 +1,267 @@
+package natsserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/handlers"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/response"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/lib/i18n"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+)
+
+const (
+	serviceName    = "goods"
+	serviceVersion = "1.0.0"
+
+	acceptLanguageHeader = "Accept-Language"
+
+	defaultLimit  = 10
+	defaultOffset = 1
+)
+
+// Server answers goods.* requests with the same models as the REST API.
+// Errors are replied with the Nats-Service-Error headers (the code header is
+// the HTTP status) and the REST error envelope as the body.
+type Server struct {
+	log             *slog.Logger
+	service         micro.Service
+	serviceProvider handlers.ServiceProvider
+	timeout         time.Duration
+}
+
+type listRequest struct {
+	Limit          *int  `json:"limit"`
+	Offset         *int  `json:"offset"`
+	IncludeRemoved *bool `json:"include_removed"`
+}
+
+func NewServer(log *slog.Logger, nc *nats.Conn, provider handlers.ServiceProvider, cfg config.MessageBroker) (*Server, error) {
+	const op = "natsserver.NewServer"
+
+	s := &Server{
+		log:             log,
+		serviceProvider: provider,
+		timeout:         cfg.RequestTimeout,
+	}
+
+	service, err := micro.AddService(nc, micro.Config{
+		Name:        serviceName,
+		Version:     serviceVersion,
+		Description: "goods request-reply API",
+		QueueGroup:  cfg.QueueGroup,
+	})
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	endpoints := map[string]micro.HandlerFunc{
+		"create":       s.handle("goods.create", s.createGood),
+		"update":       s.handle("goods.update", s.updateGood),
+		"delete":       s.handle("goods.delete", s.deleteGood),
+		"restore":      s.handle("goods.restore", s.restoreGood),
+		"reprioritize": s.handle("goods.reprioritize", s.reprioritizeGood),
+		"list":         s.handle("goods.list", s.listGoods),
+	}
+
+	group := service.AddGroup(serviceName)
+	for name, handler := range endpoints {
+		if err := group.AddEndpoint(name, handler); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+	}
+
+	s.service = service
+
+	return s, nil
+}
+
+// Stop drains the endpoint subscriptions.
+func (s *Server) Stop() error {
+	return s.service.Stop()
+}
+
+func (s *Server) handle(subject string, fn func(ctx context.Context, data []byte) (any, error)) micro.HandlerFunc {
+	log := s.log.With(slog.String("op", "natsserver."+subject))
+
+	return func(req micro.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+
+		output, err := fn(ctx, req.Data())
+		if err != nil {
+			s.respondError(log, req, err)
+			return
+		}
+
+		if err := req.RespondJSON(output); err != nil {
+			log.Error("couldn't send reply", slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (s *Server) respondError(log *slog.Logger, req micro.Request, err error) {
+	appErr := apperr.ErrInternal
+	errors.As(err, &appErr)
+
+	statusCode := response.Status(appErr.Kind)
+	if appErr.Kind == apperr.Internal {
+		log.Error(err.Error())
+	} else {
+		log.Info(err.Error())
+	}
+
+	lang := i18n.Match(req.Headers().Get(acceptLanguageHeader))
+	message := response.Translate(lang, appErr)
+
+	data, err := json.Marshal(response.ErrorResponse{Error: response.ErrorBody{
+		Code:    appErr.Code,
+		Message: message,
+		Details: response.TranslateDetails(lang, appErr.Details),
+	}})
+	if err != nil {
+		log.Error("couldn't marshal error reply", slog.String("error", err.Error()))
+		return
+	}
+
+	if err := req.Error(strconv.Itoa(statusCode), message, data); err != nil {
+		log.Error("couldn't send error reply", slog.String("error", err.Error()))
+	}
+}
+
+func (s *Server) createGood(ctx context.Context, data []byte) (any, error) {
+	var input models.CreateRequest
+	if err := decode(data, &input); err != nil {
+		return nil, err
+	}
+
+	if err := requireID("project_id", input.ProjectID); err != nil {
+		return nil, err
+	}
+
+	return s.serviceProvider.CreateGood(ctx, &input)
+}
+
+func (s *Server) updateGood(ctx context.Context, data []byte) (any, error) {
+	var input models.UpdateRequest
+	if err := decode(data, &input); err != nil {
+		return nil, err
+	}
+
+	if err := requireIDs(input.ID, input.ProjectID); err != nil {
+		return nil, err
+	}
+
+	return s.serviceProvider.UpdateGood(ctx, &input)
+}
+
+func (s *Server) deleteGood(ctx context.Context, data []byte) (any, error) {
+	var input models.DeleteRequest
+	if err := decode(data, &input); err != nil {
+		return nil, err
+	}
+
+	if err := requireIDs(input.ID, input.ProjectID); err != nil {
+		return nil, err
+	}
+
+	return s.serviceProvider.DeleteGood(ctx, &input)
+}
+
+func (s *Server) restoreGood(ctx context.Context, data []byte) (any, error) {
+	var input models.RestoreRequest
+	if err := decode(data, &input); err != nil {
+		return nil, err
+	}
+
+	if err := requireIDs(input.ID, input.ProjectID); err != nil {
+		return nil, err
+	}
+
+	return s.serviceProvider.RestoreGood(ctx, &input)
+}
+
+// reprioritizeGood takes the ids in the body, unlike the REST route where
+// they are part of the path.
+func (s *Server) reprioritizeGood(ctx context.Context, data []byte) (any, error) {
+	var input struct {
+		models.ReprioritizeRequest
+		ID        int `json:"id"`
+		ProjectID int `json:"project_id"`
+	}
+	if err := decode(data, &input); err != nil {
+		return nil, err
+	}
+
+	if err := requireIDs(input.ID, input.ProjectID); err != nil {
+		return nil, err
+	}
+
+	input.ReprioritizeRequest.ID, input.ReprioritizeRequest.ProjectID = input.ID, input.ProjectID
+
+	return s.serviceProvider.ReprioritizeGood(ctx, &input.ReprioritizeRequest)
+}
+
+func (s *Server) listGoods(ctx context.Context, data []byte) (any, error) {
+	var input listRequest
+	if len(data) > 0 {
+		if err := decode(data, &input); err != nil {
+			return nil, err
+		}
+	}
+
+	limit, offset, includeRemoved := defaultLimit, defaultOffset, true
+	if input.Limit != nil {
+		limit = *input.Limit
+	}
+	if input.Offset != nil {
+		offset = *input.Offset
+	}
+	if input.IncludeRemoved != nil {
+		includeRemoved = *input.IncludeRemoved
+	}
+
+	if limit < 1 {
+		return nil, apperr.ErrInvalidParam.WithField("limit", apperr.CodeMin, 1)
+	}
+	if offset < 0 {
+		return nil, apperr.ErrInvalidParam.WithField("offset", apperr.CodeMin, 0)
+	}
+
+	return s.serviceProvider.GetGoods(ctx, 0, limit, offset, includeRemoved)
+}
+
+// decode unmarshals and validates the request body like ShouldBindJSON does
+// for the REST handlers.
+func decode(data []byte, input any) error {
+	if err := json.Unmarshal(data, input); err != nil {
+		return apperr.ErrInvalidBody
+	}
+
+	return handlers.Validate(input)
+}
+
+func requireID(field string, id int) error {
+	if id <= 0 {
+		return apperr.ErrInvalidParam.WithField(field, apperr.CodeRequired)
+	}
+
+	return nil
+}
+
+func requireIDs(id, projectID int) error {
+	if err := requireID("id", id); err != nil {
+		return err
+	}
+
+	return requireID("project_id", projectID)
+}
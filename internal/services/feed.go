@@ -0,0 +1,168 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+)
+
+// FeedService fans the good logs out to the stream subscribers of each
+// project. The latest events are kept in a ring buffer, so a reconnecting
+// client can resume after its Last-Event-ID.
+//
+// Event ids are "<instance>-<sequence>": ids issued by another instance (or
+// before a restart) can't be resumed and the subscription is reset instead.
+type FeedService struct {
+	log              *slog.Logger
+	instance         string
+	subscriberBuffer int
+
+	mu          sync.Mutex
+	sequence    uint64
+	events      []models.FeedEvent
+	subscribers map[*models.FeedSubscription]chan models.FeedEvent
+}
+
+const (
+	defaultFeedBufferSize       = 1024
+	defaultFeedSubscriberBuffer = 64
+)
+
+func NewFeedService(log *slog.Logger, cfg config.Feed) *FeedService {
+	// The ring buffer is indexed modulo its size, so it can't be empty.
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultFeedBufferSize
+	}
+	if cfg.SubscriberBuffer <= 0 {
+		cfg.SubscriberBuffer = defaultFeedSubscriberBuffer
+	}
+
+	instance := make([]byte, 4)
+	_, _ = rand.Read(instance)
+
+	return &FeedService{
+		log:              log,
+		instance:         hex.EncodeToString(instance),
+		subscriberBuffer: cfg.SubscriberBuffer,
+		events:           make([]models.FeedEvent, cfg.BufferSize),
+		subscribers:      make(map[*models.FeedSubscription]chan models.FeedEvent),
+	}
+}
+
+// Publish is called with every batch of logs received from the broker.
+func (s *FeedService) Publish(logs []models.GoodLog) {
+	const op = "services.feed.Publish"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, value := range logs {
+		s.sequence++
+
+		event := models.FeedEvent{
+			ID:        fmt.Sprintf("%s-%d", s.instance, s.sequence),
+			Event:     value.Event,
+			EventTime: value.EventTime,
+			Good: models.FeedGood{
+				ID:          value.ID,
+				ProjectID:   value.ProjectID,
+				Name:        value.Name,
+				Description: value.Description,
+				Priority:    value.Priority,
+				Removed:     value.Removed,
+			},
+		}
+		s.events[s.sequence%uint64(len(s.events))] = event
+
+		for sub, ch := range s.subscribers {
+			if sub.ProjectID != value.ProjectID {
+				continue
+			}
+
+			select {
+			case ch <- event:
+			default:
+				// a slow subscriber would block everyone else, so it is
+				// dropped and resumes from the buffer on reconnect
+				s.log.Warn("dropping slow feed subscriber", slog.String("op", op), slog.Int("project_id", sub.ProjectID))
+				delete(s.subscribers, sub)
+				close(ch)
+			}
+		}
+	}
+}
+
+// Subscribe starts receiving the events of the project. With a lastEventID
+// the buffered events after it are returned in Replay.
+func (s *FeedService) Subscribe(projectID int, lastEventID string) *models.FeedSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan models.FeedEvent, s.subscriberBuffer)
+	sub := &models.FeedSubscription{ProjectID: projectID, Events: ch}
+
+	if lastEventID != "" {
+		sub.Replay, sub.Reset = s.replay(projectID, lastEventID)
+	}
+
+	s.subscribers[sub] = ch
+
+	return sub
+}
+
+func (s *FeedService) Unsubscribe(sub *models.FeedSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ch, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(ch)
+	}
+}
+
+// Close ends every subscription, so that open streams return on shutdown and
+// their clients reconnect to another instance.
+func (s *FeedService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub, ch := range s.subscribers {
+		delete(s.subscribers, sub)
+		close(ch)
+	}
+}
+
+// replay must be called with s.mu held.
+func (s *FeedService) replay(projectID int, lastEventID string) ([]models.FeedEvent, bool) {
+	instance, sequence, ok := strings.Cut(lastEventID, "-")
+	if !ok || instance != s.instance {
+		return nil, true
+	}
+
+	last, err := strconv.ParseUint(sequence, 10, 64)
+	if err != nil || last > s.sequence {
+		return nil, true
+	}
+
+	size := uint64(len(s.events))
+	if s.sequence-last > size {
+		return nil, true
+	}
+
+	var events []models.FeedEvent
+	for seq := last + 1; seq <= s.sequence; seq++ {
+		event := s.events[seq%size]
+		if event.Good.ProjectID == projectID {
+			events = append(events, event)
+		}
+	}
+
+	return events, false
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/lib/breaker"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+)
+
+// HealthService runs the dependency checks of the readiness probe.
+type HealthService struct {
+	log             *slog.Logger
+	timeout         time.Duration
+	checks          []HealthCheck
+	breakerProvider BreakerProvider
+}
+
+// HealthCheck reaches one dependency. An optional one that fails degrades the
+// application without making it unready.
+type HealthCheck struct {
+	Name     string
+	Optional bool
+	Check    func(ctx context.Context) error
+}
+
+type BreakerProvider interface {
+	BreakerStats() breaker.Stats
+}
+
+func NewHealthService(log *slog.Logger, cfg config.Readiness, breakerProvider BreakerProvider, checks ...HealthCheck) *HealthService {
+	return &HealthService{
+		log:             log,
+		timeout:         cfg.Timeout,
+		checks:          checks,
+		breakerProvider: breakerProvider,
+	}
+}
+
+func (s *HealthService) BreakerStats() breaker.Stats {
+	return s.breakerProvider.BreakerStats()
+}
+
+// Ready runs the checks at once, each bounded by the readiness timeout.
+func (s *HealthService) Ready(ctx context.Context) *models.Readiness {
+	const op = "services.Ready"
+
+	log := s.log.With(slog.String("op", op))
+
+	statuses := make([]models.DependencyStatus, len(s.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			statuses[i] = s.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	readiness := &models.Readiness{
+		Status:       models.ReadinessReady,
+		Dependencies: make(map[string]models.DependencyStatus, len(s.checks)),
+	}
+
+	for i, check := range s.checks {
+		status := statuses[i]
+		readiness.Dependencies[check.Name] = status
+
+		if status.Status == models.DependencyUp {
+			continue
+		}
+
+		log.Warn("dependency check failed", slog.String("dependency", check.Name), slog.String("error", status.Error))
+
+		switch {
+		case !check.Optional:
+			readiness.Status = models.ReadinessUnavailable
+		case readiness.Status == models.ReadinessReady:
+			readiness.Status = models.ReadinessDegraded
+		}
+	}
+
+	return readiness
+}
+
+func (s *HealthService) run(ctx context.Context, check HealthCheck) models.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+
+	status := models.DependencyStatus{
+		Status:    models.DependencyUp,
+		Optional:  check.Optional,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		status.Status = models.DependencyDown
+		status.Error = err.Error()
+	}
+
+	return status
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/IskanderSh/hezzl-task/internal/storage/clickhouse"
+)
+
+var (
+	ErrHistoryUnavailable = apperr.New(apperr.Unavailable, "errors.history.Unavailable", "goods history is temporarily unavailable")
+)
+
+// HistoryService reads the audit events the broker client saves to ClickHouse.
+type HistoryService struct {
+	log             *slog.Logger
+	historyProvider HistoryProvider
+}
+
+type HistoryProvider interface {
+	GetGoodsHistory(ctx context.Context, keys []models.GoodKey, limit int) (map[models.GoodKey][]models.GoodLog, error)
+}
+
+func NewHistoryService(log *slog.Logger, provider HistoryProvider) *HistoryService {
+	return &HistoryService{
+		log:             log,
+		historyProvider: provider,
+	}
+}
+
+func (s *HistoryService) GetGoodsHistory(ctx context.Context, keys []models.GoodKey, limit int) (map[models.GoodKey][]models.GoodLog, error) {
+	const op = "services.GetGoodsHistory"
+
+	history, err := s.historyProvider.GetGoodsHistory(ctx, keys, limit)
+	if err != nil {
+		if errors.Is(err, clickhouse.ErrUnavailable) {
+			return nil, wrapper.Wrap(op, ErrHistoryUnavailable)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return history, nil
+}
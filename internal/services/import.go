@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	storage "github.com/IskanderSh/hezzl-task/internal/storage/postgres"
+)
+
+type ImportService struct {
+	log             *slog.Logger
+	storageProvider ImportStorageProvider
+	cacheProvider   ImportCacheProvider
+	brokerProvider  BrokerProvider
+}
+
+type ImportStorageProvider interface {
+	BeginImport(ctx context.Context, projectID int) (*storage.GoodsImport, error)
+}
+
+type ImportCacheProvider interface {
+	SetMaxPriority(ctx context.Context, priority int) error
+	SaveGoods(ctx context.Context, values map[string]*models.GoodCache) error
+	SaveSuggestions(ctx context.Context, goods *[]models.Good) error
+}
+
+func NewImportService(log *slog.Logger, provider ImportStorageProvider, cache ImportCacheProvider, broker BrokerProvider) *ImportService {
+	return &ImportService{
+		log:             log,
+		storageProvider: provider,
+		cacheProvider:   cache,
+		brokerProvider:  broker,
+	}
+}
+
+const (
+	importFieldName        = "name"
+	importFieldDescription = "description"
+	importFieldSKU         = "sku"
+
+	maxNameLength   = 255
+	maxSKULength    = 255
+	maxImportErrors = 100
+)
+
+var (
+	ErrInvalidImport = apperr.New(apperr.Validation, "errors.import.Invalid", "invalid import request")
+)
+
+// rowReader yields source records as field name to value maps along with
+// their line in the source; io.EOF ends the stream.
+type rowReader interface {
+	Next() (map[string]string, int, error)
+}
+
+// ImportGoods reads goods from r row by row, validates them and streams the valid
+// ones into storage. Invalid rows are skipped and listed in the report.
+func (s *ImportService) ImportGoods(ctx context.Context, req *models.ImportRequest, r io.Reader) (*models.ImportReport, error) {
+	const op = "services.ImportGoods"
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := validateImport(req); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	reader, err := newRowReader(req.Format, r)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	session, err := s.storageProvider.BeginImport(ctx, req.ProjectID)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+	defer func() {
+		if err := session.Rollback(); err != nil {
+			log.Warn("couldn't roll back import", slog.String("error", err.Error()))
+		}
+	}()
+
+	report := &models.ImportReport{DryRun: req.DryRun, Errors: make([]models.ImportError, 0)}
+	addError := func(importErr models.ImportError) {
+		if len(report.Errors) < maxImportErrors {
+			report.Errors = append(report.Errors, importErr)
+		} else {
+			report.ErrorsTruncated = true
+		}
+	}
+
+	for {
+		record, line, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		report.Total++
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) && !isJSONError(err) {
+				return nil, wrapper.Wrap(op, err)
+			}
+
+			report.Invalid++
+			addError(models.ImportError{Line: line, Message: err.Error()})
+
+			if isJSONError(err) {
+				// the decoder can't resynchronise after a syntax error
+				break
+			}
+			continue
+		}
+
+		row := models.ImportRow{
+			Line:        line,
+			Name:        strings.TrimSpace(record[mappedField(req, importFieldName)]),
+			Description: record[mappedField(req, importFieldDescription)],
+			SKU:         strings.TrimSpace(record[mappedField(req, importFieldSKU)]),
+		}
+
+		if rowErrors := validateImportRow(req, &row); len(rowErrors) > 0 {
+			report.Invalid++
+			for _, rowErr := range rowErrors {
+				addError(rowErr)
+			}
+			continue
+		}
+
+		if err := session.Add(ctx, &row); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+		report.Valid++
+	}
+
+	result, err := session.Commit(ctx, req)
+	if err != nil {
+		if errors.Is(err, storage.ErrProjectNotFound) {
+			return nil, wrapper.Wrap(op, ErrProjectNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	report.Inserted = len(result.Inserted)
+	report.Updated = len(result.Updated)
+
+	for _, row := range result.Rejected {
+		report.Valid--
+		report.Invalid++
+		addError(rejectionError(&row, &result.Rules))
+	}
+
+	if !req.DryRun {
+		s.publishImported(ctx, log, result)
+	}
+
+	log.Info(fmt.Sprintf("imported goods into project %d: %d inserted, %d updated, %d invalid",
+		req.ProjectID, report.Inserted, report.Updated, report.Invalid))
+
+	return report, nil
+}
+
+// publishImported refreshes the caches with the committed goods and sends their
+// events, like the single good operations do.
+func (s *ImportService) publishImported(ctx context.Context, log *slog.Logger, result *models.ImportResult) {
+	goods := make([]models.Good, 0, len(result.Inserted)+len(result.Updated))
+	goods = append(goods, result.Inserted...)
+	goods = append(goods, result.Updated...)
+
+	if len(goods) == 0 {
+		return
+	}
+
+	// the import took its priorities under the storage lock, past the cached maximum
+	if priority := maxPriority(result.Inserted); priority > 0 {
+		if err := s.cacheProvider.SetMaxPriority(ctx, priority); err != nil {
+			log.Warn(fmt.Sprintf("couldn't save maximum of priority to cache %d", priority))
+		}
+	}
+
+	values := make(map[string]*models.GoodCache, len(goods))
+	for i := range goods {
+		key, value := makeCacheParams(&goods[i])
+		values[key] = value
+	}
+
+	if err := s.cacheProvider.SaveGoods(ctx, values); err != nil {
+		log.Warn(fmt.Sprintf("couldn't save %d imported goods to cache", len(values)))
+	}
+
+	if err := s.cacheProvider.SaveSuggestions(ctx, &goods); err != nil {
+		log.Warn(fmt.Sprintf("couldn't save %d imported suggestions to cache", len(goods)))
+	}
+
+	logs := make([]models.GoodLog, 0, len(goods))
+	for i := range result.Inserted {
+		logs = append(logs, *makeLog(&result.Inserted[i], models.EventCreate))
+	}
+	for i := range result.Updated {
+		logs = append(logs, *makeLog(&result.Updated[i], models.EventUpdate))
+	}
+
+	s.brokerProvider.SendLogs(logs)
+}
+
+func rejectionError(row *models.ImportRejection, rules *models.ProjectRules) models.ImportError {
+	importErr := models.ImportError{Line: row.Line, Field: row.Field}
+
+	switch row.Field {
+	case importFieldDescription:
+		importErr.Message = fmt.Sprintf("description is longer than %d characters", rules.MaxDescriptionLength)
+	case importFieldName:
+		importErr.Message = fmt.Sprintf("name %s is already taken", row.Name)
+	default:
+		importErr.Message = fmt.Sprintf("sku %s is already taken", row.SKU)
+	}
+
+	return importErr
+}
+
+func validateImport(req *models.ImportRequest) error {
+	if req.Format != models.ImportFormatCSV && req.Format != models.ImportFormatNDJSON {
+		return ErrInvalidImport.WithField("format", apperr.CodeUnknownValue, req.Format)
+	}
+
+	if req.Mode == "" {
+		req.Mode = models.ImportModeInsert
+	}
+
+	switch req.Mode {
+	case models.ImportModeInsert:
+	case models.ImportModeUpsert:
+		if req.Key == "" {
+			req.Key = models.ImportKeyName
+		}
+
+		if req.Key != models.ImportKeyName && req.Key != models.ImportKeySKU {
+			return ErrInvalidImport.WithField("key", apperr.CodeUnknownValue, req.Key)
+		}
+	default:
+		return ErrInvalidImport.WithField("mode", apperr.CodeUnknownValue, req.Mode)
+	}
+
+	for field := range req.Mapping {
+		if field != importFieldName && field != importFieldDescription && field != importFieldSKU {
+			return ErrInvalidImport.WithField("map", apperr.CodeUnknownValue, field)
+		}
+	}
+
+	return nil
+}
+
+func validateImportRow(req *models.ImportRequest, row *models.ImportRow) []models.ImportError {
+	var rowErrors []models.ImportError
+
+	switch {
+	case row.Name == "":
+		rowErrors = append(rowErrors, models.ImportError{Line: row.Line, Field: importFieldName, Message: "name is required"})
+	case utf8.RuneCountInString(row.Name) > maxNameLength:
+		rowErrors = append(rowErrors, models.ImportError{Line: row.Line, Field: importFieldName,
+			Message: fmt.Sprintf("name is longer than %d characters", maxNameLength)})
+	}
+
+	switch {
+	case row.SKU == "" && req.Mode == models.ImportModeUpsert && req.Key == models.ImportKeySKU:
+		rowErrors = append(rowErrors, models.ImportError{Line: row.Line, Field: importFieldSKU, Message: "sku is required"})
+	case utf8.RuneCountInString(row.SKU) > maxSKULength:
+		rowErrors = append(rowErrors, models.ImportError{Line: row.Line, Field: importFieldSKU,
+			Message: fmt.Sprintf("sku is longer than %d characters", maxSKULength)})
+	}
+
+	return rowErrors
+}
+
+func mappedField(req *models.ImportRequest, field string) string {
+	if column, ok := req.Mapping[field]; ok {
+		return column
+	}
+
+	return field
+}
+
+func newRowReader(format string, r io.Reader) (rowReader, error) {
+	switch format {
+	case models.ImportFormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.ReuseRecord = true
+
+		header, err := reader.Read()
+		if err != nil {
+			return nil, ErrInvalidImport.WithField("header", apperr.CodeCSVHeader)
+		}
+
+		columns := make([]string, len(header))
+		for i, column := range header {
+			columns[i] = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		}
+
+		return &csvRows{reader: reader, header: columns}, nil
+	case models.ImportFormatNDJSON:
+		return &ndjsonRows{decoder: json.NewDecoder(r)}, nil
+	default:
+		return nil, ErrInvalidImport.WithField("format", apperr.CodeUnknownValue, format)
+	}
+}
+
+type csvRows struct {
+	reader *csv.Reader
+	header []string
+}
+
+func (r *csvRows) Next() (map[string]string, int, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.Line, err
+		}
+		return nil, 0, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+
+	values := make(map[string]string, len(r.header))
+	for i, column := range r.header {
+		if i < len(record) {
+			values[column] = record[i]
+		}
+	}
+
+	return values, line, nil
+}
+
+type ndjsonRows struct {
+	decoder *json.Decoder
+	line    int
+}
+
+func (r *ndjsonRows) Next() (map[string]string, int, error) {
+	if !r.decoder.More() {
+		return nil, 0, io.EOF
+	}
+	r.line++
+
+	var object map[string]any
+	if err := r.decoder.Decode(&object); err != nil {
+		return nil, r.line, err
+	}
+
+	values := make(map[string]string, len(object))
+	for key, value := range object {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			values[key] = v
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+
+	return values, r.line, nil
+}
+
+func isJSONError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log/slog"
+	"sync"
+	"time"
 
+	"github.com/IskanderSh/hezzl-task/internal/clients"
 	"github.com/IskanderSh/hezzl-task/internal/config"
 	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
 	"github.com/IskanderSh/hezzl-task/internal/models"
@@ -12,23 +16,31 @@ import (
 )
 
 type NatsServer struct {
-	log        *slog.Logger
-	connection *nats.Conn
-	subject    string
-	logStorage []models.GoodLog
+	log           *slog.Logger
+	connection    *nats.Conn
+	subject       string
+	flushInterval time.Duration
+	mu            sync.Mutex
+	logStorage    []models.GoodLog
+	closed        <-chan struct{}
 }
 
 func NewNatsServer(log *slog.Logger, cfg config.MessageBroker) (*NatsServer, error) {
 	const op = "services.nats.NewNatsServer"
 
-	connectString := fmt.Sprintf("nats://%s:%d", cfg.Host, cfg.Port)
-
-	nc, err := nats.Connect(connectString)
+	nc, closed, err := clients.ConnectNats(log, cfg)
 	if err != nil {
 		return nil, wrapper.Wrap(op, err)
 	}
 
-	return &NatsServer{log: log, connection: nc, subject: cfg.Subject, logStorage: make([]models.GoodLog, batchSize)}, nil
+	return &NatsServer{
+		log:           log,
+		connection:    nc,
+		subject:       cfg.Subject,
+		flushInterval: cfg.FlushInterval,
+		logStorage:    make([]models.GoodLog, 0, batchSize),
+		closed:        closed,
+	}, nil
 }
 
 const (
@@ -38,16 +50,84 @@ const (
 func (s *NatsServer) SendLog(log *models.GoodLog) {
 	const op = "services.nats.SendLog"
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.logStorage = append(s.logStorage, *log)
 
 	if len(s.logStorage) == batchSize {
-		err := s.publishLogs(&s.logStorage)
-		if err != nil {
-			s.log.Error("couldn't send logs to storage", err)
+		s.flush(op)
+	}
+}
+
+// Run publishes partial batches every flush interval, so that logs reach the
+// subscribers without waiting for a full batch.
+func (s *NatsServer) Run(ctx context.Context) {
+	const op = "services.nats.Run"
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if len(s.logStorage) > 0 {
+				s.flush(op)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close publishes the buffered logs and waits until the connection has
+// flushed them to the server.
+func (s *NatsServer) Close(ctx context.Context) error {
+	const op = "services.nats.Close"
+
+	s.mu.Lock()
+	if len(s.logStorage) > 0 {
+		s.flush(op)
+	}
+	s.mu.Unlock()
+
+	if err := s.connection.Drain(); err != nil {
+		// Drain closes a connection that never connected
+		if errors.Is(err, nats.ErrConnectionReconnecting) {
+			s.log.Warn("message broker is not connected, buffered logs are lost", slog.String("op", op))
+			return nil
 		}
+		return wrapper.Wrap(op, err)
+	}
+
+	select {
+	case <-s.closed:
+		return nil
+	case <-ctx.Done():
+		s.connection.Close()
+		return wrapper.Wrap(op, ctx.Err())
+	}
+}
+
+func (s *NatsServer) Ping(ctx context.Context) error {
+	const op = "services.nats.Ping"
+
+	if err := clients.PingNats(ctx, s.connection); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
 
-		s.logStorage = make([]models.GoodLog, batchSize)
+// flush must be called with s.mu held.
+func (s *NatsServer) flush(op string) {
+	if err := s.publishLogs(&s.logStorage); err != nil {
+		s.log.Error("couldn't send logs to storage", slog.String("op", op), slog.String("error", err.Error()))
 	}
+
+	s.logStorage = make([]models.GoodLog, 0, batchSize)
 }
 
 func (s *NatsServer) publishLogs(logs *[]models.GoodLog) error {
@@ -68,3 +148,18 @@ func (s *NatsServer) publishLogs(logs *[]models.GoodLog) error {
 
 	return nil
 }
+
+func (s *NatsServer) SendLogs(logs []models.GoodLog) {
+	const op = "services.nats.SendLogs"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, log := range logs {
+		s.logStorage = append(s.logStorage, log)
+
+		if len(s.logStorage) == batchSize {
+			s.flush(op)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	storage "github.com/IskanderSh/hezzl-task/internal/storage/postgres"
+)
+
+type ProjectService struct {
+	log             *slog.Logger
+	storageProvider ProjectStorageProvider
+}
+
+type ProjectStorageProvider interface {
+	GetProjectRules(ctx context.Context, projectID int) (*models.ProjectRules, error)
+	SaveProjectRules(ctx context.Context, rules *models.ProjectRules) (*models.ProjectRules, error)
+	GetProjects(ctx context.Context, ids []int) (map[int]*models.Project, error)
+	ListProjects(ctx context.Context, limit, offset int) ([]models.Project, error)
+	GetProjectsRules(ctx context.Context, ids []int) (map[int]*models.ProjectRules, error)
+	GetProjectsGoods(ctx context.Context, ids []int, filter *models.ProjectGoodsFilter) (map[int]*models.GoodsPage, error)
+	GetGoodsByKeys(ctx context.Context, keys []models.GoodKey) (map[models.GoodKey]*models.Good, error)
+}
+
+var (
+	ErrProjectNotFound = apperr.New(apperr.NotFound, "errors.project.NotFound", "project with such id not found")
+	ErrDuplicateName   = apperr.New(apperr.Conflict, "errors.good.DuplicateName", "good with such name already exists in project")
+	ErrRuleViolation   = apperr.New(apperr.Validation, "errors.good.RuleViolation", "good violates project rules")
+)
+
+func NewProjectService(log *slog.Logger, provider ProjectStorageProvider) *ProjectService {
+	return &ProjectService{
+		log:             log,
+		storageProvider: provider,
+	}
+}
+
+func (s *ProjectService) GetRules(ctx context.Context, projectID int) (*models.ProjectRules, error) {
+	const op = "services.GetRules"
+
+	rules, err := s.storageProvider.GetProjectRules(ctx, projectID)
+	if err != nil {
+		if errors.Is(err, storage.ErrProjectNotFound) {
+			return nil, wrapper.Wrap(op, ErrProjectNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return rules, nil
+}
+
+func (s *ProjectService) UpdateRules(ctx context.Context, rules *models.ProjectRules) (*models.ProjectRules, error) {
+	const op = "services.UpdateRules"
+
+	log := s.log.With(slog.String("op", op))
+
+	saved, err := s.storageProvider.SaveProjectRules(ctx, rules)
+	if err != nil {
+		if errors.Is(err, storage.ErrProjectNotFound) {
+			return nil, wrapper.Wrap(op, ErrProjectNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	log.Info(fmt.Sprintf("updated rules of project %d", rules.ProjectID))
+
+	return saved, nil
+}
+
+// The batch getters below back the GraphQL loaders: ids that don't exist are
+// missing from the result instead of failing the whole batch.
+
+func (s *ProjectService) GetProjects(ctx context.Context, ids []int) (map[int]*models.Project, error) {
+	const op = "services.GetProjects"
+
+	projects, err := s.storageProvider.GetProjects(ctx, ids)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return projects, nil
+}
+
+func (s *ProjectService) ListProjects(ctx context.Context, limit, offset int) ([]models.Project, error) {
+	const op = "services.ListProjects"
+
+	projects, err := s.storageProvider.ListProjects(ctx, limit, offset)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return projects, nil
+}
+
+func (s *ProjectService) GetProjectsRules(ctx context.Context, ids []int) (map[int]*models.ProjectRules, error) {
+	const op = "services.GetProjectsRules"
+
+	rules, err := s.storageProvider.GetProjectsRules(ctx, ids)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return rules, nil
+}
+
+func (s *ProjectService) GetProjectsGoods(ctx context.Context, ids []int, filter *models.ProjectGoodsFilter) (map[int]*models.GoodsPage, error) {
+	const op = "services.GetProjectsGoods"
+
+	pages, err := s.storageProvider.GetProjectsGoods(ctx, ids, filter)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return pages, nil
+}
+
+func (s *ProjectService) GetGoodsByKeys(ctx context.Context, keys []models.GoodKey) (map[models.GoodKey]*models.Good, error) {
+	const op = "services.GetGoodsByKeys"
+
+	goods, err := s.storageProvider.GetGoodsByKeys(ctx, keys)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return goods, nil
+}
+
+// checkProjectRules validates the name and description a good is about to get.
+// A nil field is left as is by the request and isn't checked. The name check
+// races with concurrent writes, so the rule is best effort.
+func (s *GoodService) checkProjectRules(ctx context.Context, projectID, id int, name, description *string) error {
+	rules, err := s.storageProvider.GetProjectRules(ctx, projectID)
+	if err != nil {
+		if errors.Is(err, storage.ErrProjectNotFound) {
+			return ErrProjectNotFound
+		}
+		return err
+	}
+
+	if description != nil && rules.MaxDescriptionLength > 0 && utf8.RuneCountInString(*description) > rules.MaxDescriptionLength {
+		return ErrRuleViolation.WithField("description", apperr.CodeMaxLength, rules.MaxDescriptionLength)
+	}
+
+	if name != nil && rules.UniqueNames {
+		exists, err := s.storageProvider.GoodNameExists(ctx, projectID, *name, id)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return ErrDuplicateName.WithField("name", apperr.CodeUnique)
+		}
+	}
+
+	return nil
+}
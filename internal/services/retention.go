@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+)
+
+const (
+	retentionLockName = "retention"
+)
+
+type RetentionWorker struct {
+	log            *slog.Logger
+	cfg            config.Retention
+	purgeProvider  PurgeProvider
+	lockerProvider LockerProvider
+}
+
+type PurgeProvider interface {
+	PurgeGoods(ctx context.Context, req *models.PurgeRequest) (*models.PurgeResponse, error)
+	GetPurgeableProjects(ctx context.Context, removedBefore time.Time) ([]int, error)
+}
+
+type LockerProvider interface {
+	AcquireLock(ctx context.Context, name string, ttl time.Duration) (string, bool, error)
+	ReleaseLock(ctx context.Context, name, token string) error
+}
+
+func NewRetentionWorker(
+	log *slog.Logger,
+	cfg config.Retention,
+	purger PurgeProvider,
+	locker LockerProvider,
+) *RetentionWorker {
+	return &RetentionWorker{
+		log:            log,
+		cfg:            cfg,
+		purgeProvider:  purger,
+		lockerProvider: locker,
+	}
+}
+
+// Run purges goods removed before the retention window on every interval
+// until ctx is cancelled.
+func (w *RetentionWorker) Run(ctx context.Context) {
+	const op = "services.retention.Run"
+
+	log := w.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.purge(ctx); err != nil {
+			log.Error("couldn't purge removed goods", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("retention worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge runs a single retention pass. The redis lock makes sure that only one
+// instance purges at a time; the others skip the pass.
+func (w *RetentionWorker) purge(ctx context.Context) error {
+	const op = "services.retention.purge"
+
+	log := w.log.With(slog.String("op", op))
+
+	token, ok, err := w.lockerProvider.AcquireLock(ctx, retentionLockName, w.cfg.LockTTL)
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	if !ok {
+		log.Debug("retention lock is held by another instance")
+		return nil
+	}
+
+	defer func() {
+		if err := w.lockerProvider.ReleaseLock(context.Background(), retentionLockName, token); err != nil {
+			log.Warn("couldn't release retention lock", slog.String("error", err.Error()))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.LockTTL)
+	defer cancel()
+
+	removedBefore := time.Now().Add(-w.cfg.Window)
+
+	projects, err := w.purgeProvider.GetPurgeableProjects(ctx, removedBefore)
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	purged := 0
+
+	for _, projectID := range projects {
+		output, err := w.purgeProvider.PurgeGoods(ctx, &models.PurgeRequest{
+			ProjectID:     projectID,
+			RemovedBefore: removedBefore,
+			Limit:         w.cfg.BatchSize,
+		})
+		if err != nil {
+			return wrapper.Wrap(op, err)
+		}
+
+		purged += output.Purged
+	}
+
+	log.Info(fmt.Sprintf("retention pass purged %d goods in %d projects", purged, len(projects)))
+
+	return nil
+}
@@ -2,12 +2,17 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
 	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
 	"github.com/IskanderSh/hezzl-task/internal/models"
 	storage "github.com/IskanderSh/hezzl-task/internal/storage/postgres"
@@ -18,15 +23,27 @@ type GoodService struct {
 	storageProvider StorageProvider
 	cacheProvider   CacheProvider
 	brokerProvider  BrokerProvider
+	suggestCfg      config.Suggest
 }
 
 type StorageProvider interface {
 	Create(req *models.CreateRequest) (*models.Good, error)
-	GetAllGoods() (*[]models.Good, error)
+	GetMaxPriority(ctx context.Context) (int, error)
 	UpdateGood(req *models.UpdateRequest) (*models.Good, error)
-	DeleteGood(req *models.DeleteRequest) (*models.DeleteResponse, error)
+	DeleteGood(req *models.DeleteRequest) (*models.Good, error)
+	RestoreGood(req *models.RestoreRequest) (*models.Good, error)
+	PurgeGoods(req *models.PurgeRequest) (*[]models.Good, error)
+	GetPurgeableProjects(ctx context.Context, removedBefore time.Time) ([]int, error)
 	ListGoods(ids *[]int) (*[]models.Good, error)
+	GetGoodsByKeys(ctx context.Context, keys []models.GoodKey) (map[models.GoodKey]*models.Good, error)
 	ReprioritizeGoods(req *models.ReprioritizeRequest) (*[]models.Priorities, error)
+	SearchGoods(req *models.SearchRequest) (*[]models.SearchResult, error)
+	SuggestGoods(ctx context.Context, req *models.SuggestRequest) (*[]models.Suggestion, error)
+	BatchGoods(ctx context.Context, req *models.BatchRequest) (*[]models.BatchResult, error)
+	ExportGoods(ctx context.Context, req *models.ExportRequest, fn func(good *models.Good) error) error
+	GetGoodsChanges(ctx context.Context, filter *models.ChangesFilter) ([]models.GoodChange, int64, error)
+	GetProjectRules(ctx context.Context, projectID int) (*models.ProjectRules, error)
+	GoodNameExists(ctx context.Context, projectID int, name string, excludeID int) (bool, error)
 }
 
 type CacheProvider interface {
@@ -35,10 +52,18 @@ type CacheProvider interface {
 	SaveGood(ctx context.Context, key string, value *models.GoodCache) error
 	GetGood(ctx context.Context, key string) (string, error)
 	DeleteGood(ctx context.Context, key string) error
+	SaveSuggestion(ctx context.Context, good *models.Good) error
+	DeleteSuggestion(ctx context.Context, projectID, id int) error
+	GetSuggestions(ctx context.Context, projectID int, prefix string, limit int) (*[]models.Suggestion, error)
+	SaveGoods(ctx context.Context, values map[string]*models.GoodCache) error
+	DeleteGoods(ctx context.Context, keys []string) error
+	SaveSuggestions(ctx context.Context, goods *[]models.Good) error
+	DeleteSuggestions(ctx context.Context, goods *[]models.Good) error
 }
 
 type BrokerProvider interface {
 	SendLog(log *models.GoodLog)
+	SendLogs(logs []models.GoodLog)
 }
 
 func NewGoodService(
@@ -46,21 +71,29 @@ func NewGoodService(
 	provider StorageProvider,
 	cache CacheProvider,
 	broker BrokerProvider,
+	suggestCfg config.Suggest,
 ) *GoodService {
 	return &GoodService{
 		log:             log,
 		storageProvider: provider,
 		cacheProvider:   cache,
 		brokerProvider:  broker,
+		suggestCfg:      suggestCfg,
 	}
 }
 
 const (
-	defaultPriority = 0
+	defaultPriority       = 0
+	defaultPurgeBatchSize = 500
+	maxBatchOperations    = 1000
+	maxChangesLimit       = 1000
 )
 
 var (
-	ErrGoodNotFound = errors.New("good with such id in project not found")
+	ErrGoodNotFound    = apperr.New(apperr.NotFound, "errors.good.NotFound", "good with such id in project not found")
+	ErrVersionConflict = apperr.New(apperr.PreconditionFailed, "errors.good.VersionConflict", "good was modified by another request")
+	ErrTestFailed      = apperr.New(apperr.PreconditionFailed, "errors.good.PatchTestFailed", "good doesn't match patch test operation")
+	ErrInvalidBatch    = apperr.New(apperr.Validation, "errors.batch.Invalid", "invalid batch request")
 )
 
 func (s *GoodService) CreateGood(ctx context.Context, req *models.CreateRequest) (*models.Good, error) {
@@ -68,6 +101,10 @@ func (s *GoodService) CreateGood(ctx context.Context, req *models.CreateRequest)
 
 	log := s.log.With(slog.String("op", op))
 
+	if err := s.checkProjectRules(ctx, req.ProjectID, 0, &req.Name, nil); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
 	priority := defaultPriority
 
 	priorityString, err := s.cacheProvider.GetMaxPriority(ctx)
@@ -76,7 +113,7 @@ func (s *GoodService) CreateGood(ctx context.Context, req *models.CreateRequest)
 	} else {
 		priority, err = strconv.Atoi(priorityString)
 		if err != nil {
-			log.Warn("couldn't convert priority id to int from string", priorityString)
+			log.Warn(fmt.Sprintf("couldn't convert priority id to int from string %s", priorityString))
 		}
 	}
 
@@ -98,14 +135,20 @@ func (s *GoodService) CreateGood(ctx context.Context, req *models.CreateRequest)
 	}
 
 	if err := s.cacheProvider.SetMaxPriority(ctx, priority); err != nil {
-		log.Warn("couldn't save maximum of priority to cache", priority)
+		log.Warn(fmt.Sprintf("couldn't save maximum of priority to cache %d", priority))
 	}
 
 	key, value := makeCacheParams(good)
 	if err := s.cacheProvider.SaveGood(ctx, key, value); err != nil {
-		log.Warn("couldn't save good to cache", key)
+		log.Warn(fmt.Sprintf("couldn't save good to cache %s", key))
 	}
 
+	if err := s.cacheProvider.SaveSuggestion(ctx, good); err != nil {
+		log.Warn(fmt.Sprintf("couldn't save suggestion to cache %s", key))
+	}
+
+	s.brokerProvider.SendLog(makeLog(good, models.EventCreate))
+
 	return good, nil
 }
 
@@ -114,11 +157,37 @@ func (s *GoodService) UpdateGood(ctx context.Context, req *models.UpdateRequest)
 
 	log := s.log.With(slog.String("op", op))
 
+	var name, description *string
+	if req.Name.Set {
+		// The name column is not nullable; every transport ends up here, so
+		// explicit nulls and blanks are rejected before touching storage.
+		if !req.Name.Valid {
+			return nil, wrapper.Wrap(op, apperr.ErrInvalidBody.WithField("name", apperr.CodeNotNull))
+		}
+		if strings.TrimSpace(req.Name.Value) == "" {
+			return nil, wrapper.Wrap(op, apperr.ErrInvalidBody.WithField("name", apperr.CodeNotBlank))
+		}
+		name = &req.Name.Value
+	}
+	if req.Description.Valid {
+		description = &req.Description.Value
+	}
+
+	if err := s.checkProjectRules(ctx, req.ProjectID, req.ID, name, description); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
 	good, err := s.storageProvider.UpdateGood(req)
 	if err != nil {
 		if errors.Is(err, storage.ErrGoodNotFound) {
 			return nil, wrapper.Wrap(op, ErrGoodNotFound)
 		}
+		if errors.Is(err, storage.ErrVersionConflict) {
+			return nil, wrapper.Wrap(op, ErrVersionConflict)
+		}
+		if errors.Is(err, storage.ErrTestFailed) {
+			return nil, wrapper.Wrap(op, ErrTestFailed)
+		}
 		return nil, wrapper.Wrap(op, err)
 	}
 
@@ -127,6 +196,12 @@ func (s *GoodService) UpdateGood(ctx context.Context, req *models.UpdateRequest)
 		log.Warn(fmt.Sprintf("couldn't save good to cache %s", key))
 	}
 
+	if err := s.cacheProvider.SaveSuggestion(ctx, good); err != nil {
+		log.Warn(fmt.Sprintf("couldn't save suggestion to cache %s", key))
+	}
+
+	s.brokerProvider.SendLog(makeLog(good, models.EventUpdate))
+
 	return good, nil
 }
 
@@ -135,23 +210,189 @@ func (s *GoodService) DeleteGood(ctx context.Context, req *models.DeleteRequest)
 
 	log := s.log.With(slog.String("op", op))
 
-	output, err := s.storageProvider.DeleteGood(req)
+	good, err := s.storageProvider.DeleteGood(req)
 	if err != nil {
 		if errors.Is(err, storage.ErrGoodNotFound) {
 			return nil, wrapper.Wrap(op, ErrGoodNotFound)
 		}
+		if errors.Is(err, storage.ErrVersionConflict) {
+			return nil, wrapper.Wrap(op, ErrVersionConflict)
+		}
 		return nil, wrapper.Wrap(op, err)
 	}
 
-	key := fmt.Sprintf("%d", output.ID)
+	key := fmt.Sprintf("%d", good.ID)
 	if err := s.cacheProvider.DeleteGood(ctx, key); err != nil {
 		log.Warn(fmt.Sprintf("couldn't delete good in cache with key: %s", key))
 	}
 
-	return output, nil
+	if err := s.cacheProvider.DeleteSuggestion(ctx, good.ProjectID, good.ID); err != nil {
+		log.Warn(fmt.Sprintf("couldn't delete suggestion in cache with key: %s", key))
+	}
+
+	s.brokerProvider.SendLog(makeLog(good, models.EventDelete))
+
+	return &models.DeleteResponse{
+		ID:        good.ID,
+		ProjectID: good.ProjectID,
+		Removed:   good.Removed,
+		Version:   good.Version,
+	}, nil
+}
+
+func (s *GoodService) RestoreGood(ctx context.Context, req *models.RestoreRequest) (*models.Good, error) {
+	const op = "services.RestoreGood"
+
+	log := s.log.With(slog.String("op", op))
+
+	// the restored good rejoins the project, so it must satisfy the current rules
+	goodKey := models.GoodKey{ID: req.ID, ProjectID: req.ProjectID}
+	goods, err := s.storageProvider.GetGoodsByKeys(ctx, []models.GoodKey{goodKey})
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	removed, ok := goods[goodKey]
+	if !ok {
+		return nil, wrapper.Wrap(op, ErrGoodNotFound)
+	}
+
+	if err := s.checkProjectRules(ctx, req.ProjectID, req.ID, &removed.Name, &removed.Description); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	good, err := s.storageProvider.RestoreGood(req)
+	if err != nil {
+		if errors.Is(err, storage.ErrGoodNotFound) {
+			return nil, wrapper.Wrap(op, ErrGoodNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	key, value := makeCacheParams(good)
+	if err := s.cacheProvider.SaveGood(ctx, key, value); err != nil {
+		log.Warn(fmt.Sprintf("couldn't save good to cache %s", key))
+	}
+
+	if err := s.cacheProvider.SaveSuggestion(ctx, good); err != nil {
+		log.Warn(fmt.Sprintf("couldn't save suggestion to cache %s", key))
+	}
+
+	s.brokerProvider.SendLog(makeLog(good, models.EventRestore))
+
+	return good, nil
+}
+
+// PurgeGoods permanently deletes goods of the project removed more than
+// req.Days ago (or before req.RemovedBefore when it is set), batch by batch,
+// until nothing is left.
+func (s *GoodService) PurgeGoods(ctx context.Context, req *models.PurgeRequest) (*models.PurgeResponse, error) {
+	const op = "services.PurgeGoods"
+
+	log := s.log.With(slog.String("op", op))
+
+	if req.ProjectID <= 0 {
+		return nil, wrapper.Wrap(op, apperr.ErrInvalidParam.WithField("projectId", apperr.CodeRequired))
+	}
+
+	if req.RemovedBefore.IsZero() {
+		if req.Days < 1 {
+			return nil, wrapper.Wrap(op, apperr.ErrInvalidBody.WithField("days", apperr.CodeMin, 1))
+		}
+		req.RemovedBefore = time.Now().Add(-time.Duration(req.Days) * 24 * time.Hour)
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = defaultPurgeBatchSize
+	}
+
+	purged := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+
+		goods, err := s.storageProvider.PurgeGoods(req)
+		if err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+
+		for _, good := range *goods {
+			key := fmt.Sprintf("%d", good.ID)
+			if err := s.cacheProvider.DeleteGood(ctx, key); err != nil {
+				log.Warn(fmt.Sprintf("couldn't delete good in cache with key: %s", key))
+			}
+
+			if err := s.cacheProvider.DeleteSuggestion(ctx, good.ProjectID, good.ID); err != nil {
+				log.Warn(fmt.Sprintf("couldn't delete suggestion in cache with key: %s", key))
+			}
+
+			s.brokerProvider.SendLog(makeLog(&good, models.EventPurge))
+		}
+
+		purged += len(*goods)
+
+		if len(*goods) < req.Limit {
+			break
+		}
+	}
+
+	log.Info(fmt.Sprintf("purged %d goods removed before %s", purged, req.RemovedBefore.Format(time.RFC3339)))
+
+	return &models.PurgeResponse{Purged: purged}, nil
 }
 
-func (s *GoodService) GetGoods(ctx context.Context, limit, offset int) (*models.ListGoodsResponse, error) {
+// GetPurgeableProjects returns the projects with goods removed before
+// removedBefore, for the retention worker to purge one by one.
+func (s *GoodService) GetPurgeableProjects(ctx context.Context, removedBefore time.Time) ([]int, error) {
+	const op = "services.GetPurgeableProjects"
+
+	projects, err := s.storageProvider.GetPurgeableProjects(ctx, removedBefore)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return projects, nil
+}
+
+// GetGood returns the good from the cache, or from storage when it isn't
+// cached yet.
+func (s *GoodService) GetGood(ctx context.Context, projectID, id int) (*models.Good, error) {
+	const op = "services.GetGood"
+
+	log := s.log.With(slog.String("op", op))
+
+	key := fmt.Sprintf("%d", id)
+	if value, err := s.cacheProvider.GetGood(ctx, key); err == nil {
+		good := models.Good{}
+		if err := json.Unmarshal([]byte(value), &good); err == nil && good.ProjectID == projectID {
+			return &good, nil
+		}
+	}
+
+	goodKey := models.GoodKey{ID: id, ProjectID: projectID}
+	goods, err := s.storageProvider.GetGoodsByKeys(ctx, []models.GoodKey{goodKey})
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	good, ok := goods[goodKey]
+	if !ok {
+		return nil, wrapper.Wrap(op, ErrGoodNotFound)
+	}
+
+	key, value := makeCacheParams(good)
+	if err := s.cacheProvider.SaveGood(ctx, key, value); err != nil {
+		log.Warn(fmt.Sprintf("couldn't save good to cache %s", key))
+	}
+
+	return good, nil
+}
+
+// GetGoods lists the goods with ids from offset to offset+limit. A projectID
+// of 0 lists the goods of every project.
+func (s *GoodService) GetGoods(ctx context.Context, projectID, limit, offset int, includeRemoved bool) (*models.ListGoodsResponse, error) {
 	const op = "services.GetGoods"
 
 	log := s.log.With(slog.String("op", op))
@@ -163,7 +404,7 @@ func (s *GoodService) GetGoods(ctx context.Context, limit, offset int) (*models.
 		key := fmt.Sprintf("%d", id)
 		value, err := s.cacheProvider.GetGood(ctx, key)
 		if err != nil {
-			log.Info("there is no value in cache with key", key)
+			log.Info(fmt.Sprintf("there is no value in cache with key %s", key))
 			idsNotInCache = append(idsNotInCache, id)
 		} else {
 			good := models.Good{}
@@ -193,12 +434,20 @@ func (s *GoodService) GetGoods(ctx context.Context, limit, offset int) (*models.
 		}
 	}
 
+	goods := make([]models.Good, 0, len(output))
 	total := 0
 	removed := 0
 	for _, value := range output {
+		if projectID != 0 && value.ProjectID != projectID {
+			continue
+		}
 		if value.Removed {
+			if !includeRemoved {
+				continue
+			}
 			removed++
 		}
+		goods = append(goods, value)
 		total++
 	}
 
@@ -209,55 +458,409 @@ func (s *GoodService) GetGoods(ctx context.Context, limit, offset int) (*models.
 			Limit:   limit,
 			Offset:  offset,
 		},
-		Goods: output,
+		Goods: goods,
 	}, nil
 }
 
 func (s *GoodService) ReprioritizeGood(ctx context.Context, req *models.ReprioritizeRequest) (*models.ReprioritizeResponse, error) {
 	const op = "services.ReprioritizeGood"
 
+	log := s.log.With(slog.String("op", op))
+
 	output, err := s.storageProvider.ReprioritizeGoods(req)
 	if err != nil {
+		if errors.Is(err, storage.ErrGoodNotFound) {
+			return nil, wrapper.Wrap(op, ErrGoodNotFound)
+		}
+		if errors.Is(err, storage.ErrVersionConflict) {
+			return nil, wrapper.Wrap(op, ErrVersionConflict)
+		}
 		return nil, wrapper.Wrap(op, err)
 	}
 
+	keys := make([]models.GoodKey, 0, len(*output))
+	for _, value := range *output {
+		key := fmt.Sprintf("%d", value.ID)
+		if err := s.cacheProvider.DeleteGood(ctx, key); err != nil {
+			log.Warn(fmt.Sprintf("couldn't delete good in cache with key: %s", key))
+		}
+
+		keys = append(keys, models.GoodKey{ID: value.ID, ProjectID: req.ProjectID})
+	}
+
+	// the shifted goods changed too, so every one of them gets an event
+	goods, err := s.storageProvider.GetGoodsByKeys(ctx, keys)
+	if err != nil {
+		log.Warn("couldn't load reprioritized goods for logs", slog.String("error", err.Error()))
+	} else {
+		logs := make([]models.GoodLog, 0, len(goods))
+		for _, key := range keys {
+			if good, ok := goods[key]; ok {
+				logs = append(logs, *makeLog(good, models.EventReprioritize))
+			}
+		}
+		s.brokerProvider.SendLogs(logs)
+	}
+
 	return &models.ReprioritizeResponse{
 		Priorities: *output,
 	}, nil
 }
 
+func (s *GoodService) SearchGoods(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error) {
+	const op = "services.SearchGoods"
+
+	results, err := s.storageProvider.SearchGoods(req)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	total := 0
+	if len(*results) > 0 {
+		total = (*results)[0].Total
+	}
+
+	return &models.SearchResponse{
+		Meta: models.SearchMeta{
+			Query:  req.Query,
+			Total:  total,
+			Limit:  req.Limit,
+			Offset: req.Offset,
+		},
+		Results: *results,
+	}, nil
+}
+
+// SuggestGoods answers from the cached prefix index when it alone fills the
+// limit, otherwise it falls back to trigram similarity in storage. The whole
+// lookup is bounded by the configured timeout.
+func (s *GoodService) SuggestGoods(ctx context.Context, req *models.SuggestRequest) (*models.SuggestResponse, error) {
+	const op = "services.SuggestGoods"
+
+	log := s.log.With(slog.String("op", op))
+
+	if req.Limit <= 0 || req.Limit > s.suggestCfg.MaxLimit {
+		req.Limit = s.suggestCfg.MaxLimit
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.suggestCfg.Timeout)
+	defer cancel()
+
+	cached, err := s.cacheProvider.GetSuggestions(ctx, req.ProjectID, req.Query, req.Limit)
+	if err != nil {
+		log.Warn(fmt.Sprintf("couldn't get suggestions from cache for project %d", req.ProjectID))
+	} else if len(*cached) >= req.Limit {
+		return &models.SuggestResponse{Suggestions: *cached}, nil
+	}
+
+	suggestions, err := s.storageProvider.SuggestGoods(ctx, req)
+	if err != nil {
+		if cached != nil && len(*cached) > 0 {
+			log.Warn(fmt.Sprintf("couldn't get suggestions from storage, answering from cache for project %d", req.ProjectID))
+			return &models.SuggestResponse{Suggestions: *cached}, nil
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return &models.SuggestResponse{Suggestions: *suggestions}, nil
+}
+
+// BatchGoods applies mixed create, update and delete operations for one project
+// and then syncs the cache and the audit log for every applied operation at once.
+func (s *GoodService) BatchGoods(ctx context.Context, req *models.BatchRequest) (*models.BatchResponse, error) {
+	const op = "services.BatchGoods"
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := validateBatch(req); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	results, err := s.storageProvider.BatchGoods(ctx, req)
+	if err != nil && !errors.Is(err, storage.ErrBatchAborted) {
+		if errors.Is(err, storage.ErrProjectNotFound) {
+			return nil, wrapper.Wrap(op, ErrProjectNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	output := &models.BatchResponse{
+		Mode:    req.Mode,
+		Applied: err == nil,
+		Results: *results,
+	}
+
+	saved := make([]models.Good, 0, len(*results))
+	created := make([]models.Good, 0, len(*results))
+	removed := make([]models.Good, 0, len(*results))
+	logs := make([]models.GoodLog, 0, len(*results))
+
+	for i := range output.Results {
+		result := &output.Results[i]
+
+		if result.Err != nil {
+			result.Error = batchErrorMessage(result.Err)
+			output.Failed++
+			continue
+		}
+		output.Succeeded++
+
+		if !output.Applied {
+			continue
+		}
+
+		switch result.Op {
+		case models.BatchOpCreate:
+			saved = append(saved, *result.Good)
+			created = append(created, *result.Good)
+			logs = append(logs, *makeLog(result.Good, models.EventCreate))
+		case models.BatchOpUpdate:
+			saved = append(saved, *result.Good)
+			logs = append(logs, *makeLog(result.Good, models.EventUpdate))
+		case models.BatchOpDelete:
+			removed = append(removed, *result.Good)
+			logs = append(logs, *makeLog(result.Good, models.EventDelete))
+		}
+	}
+
+	if !output.Applied {
+		output.Succeeded = 0
+		return output, nil
+	}
+
+	// the batch took its priorities under the storage lock, past the cached maximum
+	if priority := maxPriority(created); priority > 0 {
+		if err := s.cacheProvider.SetMaxPriority(ctx, priority); err != nil {
+			log.Warn(fmt.Sprintf("couldn't save maximum of priority to cache %d", priority))
+		}
+	}
+
+	values := make(map[string]*models.GoodCache, len(saved))
+	for i := range saved {
+		key, value := makeCacheParams(&saved[i])
+		values[key] = value
+	}
+
+	if err := s.cacheProvider.SaveGoods(ctx, values); err != nil {
+		log.Warn(fmt.Sprintf("couldn't save %d goods to cache", len(values)))
+	}
+
+	if err := s.cacheProvider.SaveSuggestions(ctx, &saved); err != nil {
+		log.Warn(fmt.Sprintf("couldn't save %d suggestions to cache", len(saved)))
+	}
+
+	keys := make([]string, 0, len(removed))
+	for _, good := range removed {
+		keys = append(keys, fmt.Sprintf("%d", good.ID))
+	}
+
+	if err := s.cacheProvider.DeleteGoods(ctx, keys); err != nil {
+		log.Warn(fmt.Sprintf("couldn't delete %d goods in cache", len(keys)))
+	}
+
+	if err := s.cacheProvider.DeleteSuggestions(ctx, &removed); err != nil {
+		log.Warn(fmt.Sprintf("couldn't delete %d suggestions in cache", len(removed)))
+	}
+
+	s.brokerProvider.SendLogs(logs)
+
+	return output, nil
+}
+
+// ExportGoods streams the project's goods to fn straight from storage, without
+// collecting them in memory.
+func (s *GoodService) ExportGoods(ctx context.Context, req *models.ExportRequest, fn func(good *models.Good) error) error {
+	const op = "services.ExportGoods"
+
+	if err := s.storageProvider.ExportGoods(ctx, req, fn); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// GetChanges returns the goods changed since the token. The token pins the
+// range of transactions being read, so that a transaction committing late is
+// never skipped: it is picked up by the sync that follows.
+func (s *GoodService) GetChanges(ctx context.Context, req *models.ChangesRequest) (*models.ChangesResponse, error) {
+	const op = "services.GetChanges"
+
+	if req.Limit <= 0 || req.Limit > maxChangesLimit {
+		req.Limit = maxChangesLimit
+	}
+
+	filter, err := decodeChangesToken(req.Since)
+	if err != nil {
+		return nil, apperr.ErrInvalidParam.WithField("since", apperr.CodeSyncToken)
+	}
+	filter.ProjectID = req.ProjectID
+	filter.Limit = req.Limit + 1
+
+	changes, toXID, err := s.storageProvider.GetGoodsChanges(ctx, filter)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	output := &models.ChangesResponse{
+		Upserts:    make([]models.Good, 0, len(changes)),
+		Tombstones: make([]models.Tombstone, 0),
+		HasMore:    len(changes) > req.Limit,
+	}
+
+	if output.HasMore {
+		changes = changes[:req.Limit]
+	}
+
+	for _, change := range changes {
+		if change.Purged || change.Removed {
+			output.Tombstones = append(output.Tombstones, models.Tombstone{ID: change.ID, ProjectID: change.ProjectID})
+		} else {
+			output.Upserts = append(output.Upserts, change.Good)
+		}
+	}
+
+	next := models.ChangesFilter{FromXID: toXID}
+	if output.HasMore {
+		next = models.ChangesFilter{FromXID: filter.FromXID, ToXID: toXID, AfterSeq: changes[len(changes)-1].ChangeSeq}
+	}
+	output.Token = encodeChangesToken(&next)
+
+	return output, nil
+}
+
+func validateBatch(req *models.BatchRequest) error {
+	if req.Mode == "" {
+		req.Mode = models.BatchModeAtomic
+	}
+
+	if req.Mode != models.BatchModeAtomic && req.Mode != models.BatchModeBestEffort {
+		return ErrInvalidBatch.WithField("mode", apperr.CodeUnknownValue, req.Mode)
+	}
+
+	if len(req.Operations) == 0 || len(req.Operations) > maxBatchOperations {
+		return ErrInvalidBatch.WithField("operations", apperr.CodeOperationsCount, maxBatchOperations)
+	}
+
+	for i, operation := range req.Operations {
+		switch operation.Op {
+		case models.BatchOpCreate:
+			if !operation.Name.Valid || operation.Name.Value == "" {
+				return ErrInvalidBatch.WithField(fmt.Sprintf("operations[%d].name", i), apperr.CodeRequired)
+			}
+		case models.BatchOpUpdate, models.BatchOpDelete:
+			if operation.ID <= 0 {
+				return ErrInvalidBatch.WithField(fmt.Sprintf("operations[%d].id", i), apperr.CodeRequired)
+			}
+			if operation.Name.Set && !operation.Name.Valid {
+				return ErrInvalidBatch.WithField(fmt.Sprintf("operations[%d].name", i), apperr.CodeNotNull)
+			}
+		default:
+			return ErrInvalidBatch.WithField(fmt.Sprintf("operations[%d].op", i), apperr.CodeUnknownValue, operation.Op)
+		}
+	}
+
+	return nil
+}
+
+func batchErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, storage.ErrGoodNotFound):
+		return ErrGoodNotFound.Code
+	case errors.Is(err, storage.ErrVersionConflict):
+		return ErrVersionConflict.Code
+	case errors.Is(err, storage.ErrNameTaken):
+		return ErrDuplicateName.Code
+	case errors.Is(err, storage.ErrDescriptionTooLong):
+		return ErrRuleViolation.Code
+	default:
+		return "errors.batch.OperationFailed"
+	}
+}
+
 func (s *GoodService) getMaxPriorityID(ctx context.Context) (int, error) {
 	const op = "services.getMaxPriorityID"
 
-	//log := s.log.With(slog.String("op", op))
-
-	values, err := s.storageProvider.GetAllGoods()
+	priority, err := s.storageProvider.GetMaxPriority(ctx)
 	if err != nil {
 		return 0, wrapper.Wrap(op, err)
 	}
 
-	maxPriority := defaultPriority
-	for _, value := range *values {
-		priority := value.Priority
+	return priority, nil
+}
 
-		if priority > maxPriority {
-			maxPriority = priority
-		}
+func maxPriority(goods []models.Good) int {
+	priority := 0
+	for _, good := range goods {
+		priority = max(priority, good.Priority)
 	}
 
-	return maxPriority, nil
+	return priority
 }
 
 func makeCacheParams(good *models.Good) (string, *models.GoodCache) {
 	key := fmt.Sprintf("%d", good.ID)
 	value := &models.GoodCache{
+		ID:          good.ID,
 		ProjectID:   good.ProjectID,
 		Name:        good.Name,
 		Description: good.Description,
 		Priority:    good.Priority,
 		Removed:     good.Removed,
+		RemovedAt:   good.RemovedAt,
 		CreatedAt:   good.CreatedAt,
+		Version:     good.Version,
+		SKU:         good.SKU,
 	}
 
 	return key, value
 }
+
+func makeLog(good *models.Good, event string) *models.GoodLog {
+	return &models.GoodLog{
+		ID:          good.ID,
+		ProjectID:   good.ProjectID,
+		Name:        good.Name,
+		Description: good.Description,
+		Priority:    good.Priority,
+		Removed:     good.Removed,
+		EventTime:   time.Now(),
+		Event:       event,
+	}
+}
+
+// encodeChangesToken and decodeChangesToken keep the sync position opaque to
+// clients.
+func encodeChangesToken(filter *models.ChangesFilter) string {
+	value := fmt.Sprintf("%d.%d.%d", filter.FromXID, filter.ToXID, filter.AfterSeq)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func decodeChangesToken(token string) (*models.ChangesFilter, error) {
+	filter := &models.ChangesFilter{}
+	if token == "" {
+		return filter, nil
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(string(value), ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed changes token")
+	}
+
+	numbers := make([]int64, len(parts))
+	for i, part := range parts {
+		numbers[i], err = strconv.ParseInt(part, 10, 64)
+		if err != nil || numbers[i] < 0 {
+			return nil, errors.New("malformed changes token")
+		}
+	}
+
+	filter.FromXID, filter.ToXID, filter.AfterSeq = numbers[0], numbers[1], numbers[2]
+
+	return filter, nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/models"
+)
+
+// Cached goods are read back into models.Good by GetGoods, so every field
+// has to survive the round trip.
+func TestCacheParamsRoundTrip(t *testing.T) {
+	removedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	good := models.Good{
+		ID:          12,
+		ProjectID:   7,
+		Name:        "good",
+		Description: "description",
+		Priority:    3,
+		Removed:     true,
+		RemovedAt:   &removedAt,
+		CreatedAt:   time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC),
+		Version:     4,
+		SKU:         "SKU-1",
+	}
+
+	key, value := makeCacheParams(&good)
+	if key != "12" {
+		t.Errorf("key %q, want 12", key)
+	}
+
+	data, err := value.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cached models.Good
+	if err := json.Unmarshal(data, &cached); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cached, good) {
+		t.Errorf("got %+v, want %+v", cached, good)
+	}
+}
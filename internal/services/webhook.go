@@ -0,0 +1,592 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/lib/retry"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	storage "github.com/IskanderSh/hezzl-task/internal/storage/postgres"
+)
+
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookSignatureHeader is "sha256=" followed by the hex HMAC-SHA256 of
+	// "<timestamp>.<body>" keyed with the webhook secret.
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	webhookSecretBytes   = 32
+	webhookEventIDBytes  = 16
+	webhookErrorMaxLen   = 1000
+	webhookBodyReadLimit = 64 << 10
+	webhookUserAgent     = "hezzl-task-webhooks"
+	webhookDialTimeout   = 30 * time.Second
+)
+
+var (
+	ErrWebhookNotFound = apperr.New(apperr.NotFound, "errors.webhook.NotFound", "webhook with such id in project not found")
+
+	errWebhookAddress   = errors.New("webhook address is not public")
+	errWebhookQueueFull = errors.New("webhook queue is full, event dropped")
+)
+
+// deniedPrefixes are the loopback, private, shared, link-local, reserved and
+// translated ranges webhooks may not reach unless AllowPrivate is set.
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// WebhookService manages the webhooks of projects and delivers good events to
+// them. Pending retries live in memory only and are lost on restart.
+//
+// A worker makes one attempt per job: retries wait for their backoff on a
+// timer and are queued again, so failing receivers don't hold the workers.
+type WebhookService struct {
+	log             *slog.Logger
+	storageProvider WebhookStorageProvider
+	client          *http.Client
+	cfg             config.Webhooks
+	backoff         retry.Policy
+	jobs            chan webhookJob
+}
+
+type WebhookStorageProvider interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context, projectID int) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, projectID, id int) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, projectID, id int) error
+	ListActiveWebhooks(ctx context.Context, projectIDs []int) ([]models.Webhook, error)
+	RecordWebhookResult(ctx context.Context, id int, success bool, disableAfter int) (bool, error)
+	SaveWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, projectID, webhookID, limit, offset int) ([]models.WebhookDelivery, error)
+}
+
+type webhookJob struct {
+	webhook models.Webhook
+	event   models.FeedEvent
+	// attempt is the number of attempts already made
+	attempt int
+}
+
+func NewWebhookService(log *slog.Logger, provider WebhookStorageProvider, cfg config.Webhooks) *WebhookService {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivate {
+		// The address is checked once resolved, so a DNS name can't be
+		// pointed at an internal service after the webhook was validated. A
+		// proxy would be dialed instead of the receiver and hide its address.
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout: webhookDialTimeout,
+			Control: dialPublicOnly,
+		}).DialContext
+	}
+
+	return &WebhookService{
+		log:             log,
+		storageProvider: provider,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			// a redirect counts as a failed delivery instead of being followed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg:     cfg,
+		backoff: retry.Policy{Delay: cfg.BaseBackoff, MaxDelay: cfg.MaxBackoff},
+		jobs:    make(chan webhookJob, cfg.QueueSize),
+	}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, req *models.WebhookRequest) (*models.Webhook, error) {
+	const op = "services.CreateWebhook"
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := s.checkURL(ctx, req.URL); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		value := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(value); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+		secret = hex.EncodeToString(value)
+	}
+
+	webhook, err := s.storageProvider.CreateWebhook(ctx, &models.Webhook{
+		ProjectID: req.ProjectID,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    webhookEvents(req.Events),
+		Enabled:   req.Enabled == nil || *req.Enabled,
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrProjectNotFound) {
+			return nil, wrapper.Wrap(op, ErrProjectNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	log.Info(fmt.Sprintf("created webhook %d of project %d", webhook.ID, webhook.ProjectID))
+
+	return webhook, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context, projectID int) ([]models.Webhook, error) {
+	const op = "services.ListWebhooks"
+
+	webhooks, err := s.storageProvider.ListWebhooks(ctx, projectID)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, projectID, id int) (*models.Webhook, error) {
+	const op = "services.GetWebhook"
+
+	webhook, err := s.storageProvider.GetWebhook(ctx, projectID, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			return nil, wrapper.Wrap(op, ErrWebhookNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	webhook.Secret = ""
+
+	return webhook, nil
+}
+
+// UpdateWebhook replaces the webhook. The secret and the enabled flag are kept
+// when they are not given.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, req *models.WebhookRequest) (*models.Webhook, error) {
+	const op = "services.UpdateWebhook"
+
+	if err := s.checkURL(ctx, req.URL); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	current, err := s.GetWebhook(ctx, req.ProjectID, req.ID)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	enabled := current.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	webhook, err := s.storageProvider.UpdateWebhook(ctx, &models.Webhook{
+		ID:        req.ID,
+		ProjectID: req.ProjectID,
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Events:    webhookEvents(req.Events),
+		Enabled:   enabled,
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			return nil, wrapper.Wrap(op, ErrWebhookNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	if req.Secret == "" {
+		webhook.Secret = ""
+	}
+
+	return webhook, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, projectID, id int) error {
+	const op = "services.DeleteWebhook"
+
+	if err := s.storageProvider.DeleteWebhook(ctx, projectID, id); err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			return wrapper.Wrap(op, ErrWebhookNotFound)
+		}
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, projectID, webhookID, limit, offset int) ([]models.WebhookDelivery, error) {
+	const op = "services.ListDeliveries"
+
+	if _, err := s.GetWebhook(ctx, projectID, webhookID); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	deliveries, err := s.storageProvider.ListWebhookDeliveries(ctx, projectID, webhookID, limit, offset)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return deliveries, nil
+}
+
+// Publish is called with every batch of logs received from the broker and
+// queues a delivery for each webhook subscribed to the event.
+func (s *WebhookService) Publish(logs []models.GoodLog) {
+	const op = "services.webhook.Publish"
+
+	log := s.log.With(slog.String("op", op))
+
+	projectIDs := make([]int, 0, len(logs))
+	for _, value := range logs {
+		if !slices.Contains(projectIDs, value.ProjectID) {
+			projectIDs = append(projectIDs, value.ProjectID)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	webhooks, err := s.storageProvider.ListActiveWebhooks(ctx, projectIDs)
+	if err != nil {
+		log.Error("couldn't load webhooks", slog.String("error", err.Error()))
+		return
+	}
+
+	if len(webhooks) == 0 {
+		return
+	}
+
+	for _, value := range logs {
+		event, err := makeWebhookEvent(&value)
+		if err != nil {
+			log.Error("couldn't make webhook event", slog.String("error", err.Error()))
+			return
+		}
+
+		for _, webhook := range webhooks {
+			if webhook.ProjectID != value.ProjectID {
+				continue
+			}
+			if len(webhook.Events) > 0 && !slices.Contains(webhook.Events, value.Event) {
+				continue
+			}
+
+			s.enqueue(webhookJob{webhook: webhook, event: event})
+		}
+	}
+}
+
+// Run delivers the queued events until ctx is done.
+func (s *WebhookService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < s.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.jobs:
+					s.deliver(ctx, &job)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// deliver makes the next attempt of the job and logs it. A retryable failure
+// is queued again after the backoff; a delivery that fails its last attempt
+// counts towards disabling.
+func (s *WebhookService) deliver(ctx context.Context, job *webhookJob) {
+	const op = "services.webhook.deliver"
+
+	log := s.log.With(slog.String("op", op), slog.Int("webhook_id", job.webhook.ID), slog.String("event_id", job.event.ID))
+
+	body, err := json.Marshal(job.event)
+	if err != nil {
+		log.Error("couldn't marshal webhook event", slog.String("error", err.Error()))
+		return
+	}
+
+	job.attempt++
+
+	delivery := s.send(ctx, &job.webhook, &job.event, body)
+	delivery.Attempt = job.attempt
+
+	if err := s.storageProvider.SaveWebhookDelivery(ctx, delivery); err != nil {
+		log.Warn("couldn't save webhook delivery", slog.String("error", err.Error()))
+	}
+
+	if !delivery.Success && retryable(delivery) && job.attempt < s.cfg.MaxAttempts {
+		retried := *job
+		time.AfterFunc(s.backoff.Backoff(job.attempt), func() {
+			s.enqueue(retried)
+		})
+		return
+	}
+
+	enabled, err := s.storageProvider.RecordWebhookResult(ctx, job.webhook.ID, delivery.Success, s.cfg.DisableAfter)
+	if err != nil {
+		log.Warn("couldn't record webhook result", slog.String("error", err.Error()))
+		return
+	}
+
+	if !enabled {
+		log.Warn("webhook disabled after repeated failures")
+	}
+}
+
+// enqueue queues the job for the workers. When the queue is full the event is
+// dropped and the attempt it would have been is logged as failed.
+func (s *WebhookService) enqueue(job webhookJob) {
+	const op = "services.webhook.enqueue"
+
+	select {
+	case s.jobs <- job:
+		return
+	default:
+	}
+
+	log := s.log.With(slog.String("op", op), slog.Int("webhook_id", job.webhook.ID), slog.String("event_id", job.event.ID))
+	log.Warn("webhook queue is full, dropping event")
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	if err := s.storageProvider.SaveWebhookDelivery(ctx, &models.WebhookDelivery{
+		WebhookID: job.webhook.ID,
+		EventID:   job.event.ID,
+		Event:     job.event.Event,
+		Attempt:   job.attempt + 1,
+		Error:     deliveryError(errWebhookQueueFull),
+	}); err != nil {
+		log.Warn("couldn't save webhook delivery", slog.String("error", err.Error()))
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, webhook *models.Webhook, event *models.FeedEvent, body []byte) *models.WebhookDelivery {
+	delivery := &models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		Event:     event.Event,
+	}
+
+	start := time.Now()
+	defer func() {
+		delivery.DurationMS = int(time.Since(start).Milliseconds())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = deliveryError(err)
+		return delivery
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(WebhookIDHeader, event.ID)
+	req.Header.Set(WebhookEventHeader, event.Event)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, timestamp, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		delivery.Error = deliveryError(err)
+		return delivery
+	}
+	defer res.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, webhookBodyReadLimit))
+
+	delivery.StatusCode = &res.StatusCode
+	delivery.Success = res.StatusCode >= 200 && res.StatusCode < 300
+
+	return delivery
+}
+
+// checkURL rejects webhooks on loopback, private and link-local addresses
+// unless they are allowed. Names that don't resolve yet are accepted, the
+// dialer checks the address again on every delivery.
+func (s *WebhookService) checkURL(ctx context.Context, rawURL string) error {
+	if s.cfg.AllowPrivate {
+		return nil
+	}
+
+	value, err := url.Parse(rawURL)
+	if err != nil {
+		return apperr.ErrInvalidBody.WithField("url", apperr.CodeURL)
+	}
+
+	host := value.Hostname()
+
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else if ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host); err == nil {
+		addrs = ips
+	}
+
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return apperr.ErrInvalidBody.WithField("url", apperr.CodePublicAddress)
+		}
+	}
+
+	return nil
+}
+
+func dialPublicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if addr, err := netip.ParseAddr(host); err != nil || !publicAddr(addr) {
+		return fmt.Errorf("%w: %s", errWebhookAddress, host)
+	}
+
+	return nil
+}
+
+func publicAddr(addr netip.Addr) bool {
+	// IPv4-mapped addresses are checked as IPv4, and zoned ones never match a
+	// prefix
+	addr = addr.Unmap().WithZone("")
+
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return addr.IsValid()
+}
+
+// SignWebhook returns the signature header value receivers should compare
+// against (with hmac.Equal).
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryable tells whether another attempt may succeed: client errors other
+// than timeouts and rate limits won't change on their own.
+func retryable(delivery *models.WebhookDelivery) bool {
+	if delivery.StatusCode == nil {
+		return true
+	}
+
+	code := *delivery.StatusCode
+
+	return code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+}
+
+func makeWebhookEvent(value *models.GoodLog) (models.FeedEvent, error) {
+	id := make([]byte, webhookEventIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return models.FeedEvent{}, err
+	}
+
+	return models.FeedEvent{
+		ID:        hex.EncodeToString(id),
+		Event:     value.Event,
+		EventTime: value.EventTime,
+		Good: models.FeedGood{
+			ID:          value.ID,
+			ProjectID:   value.ProjectID,
+			Name:        value.Name,
+			Description: value.Description,
+			Priority:    value.Priority,
+			Removed:     value.Removed,
+		},
+	}, nil
+}
+
+func webhookEvents(events []string) []string {
+	if events == nil {
+		return []string{}
+	}
+
+	return events
+}
+
+func deliveryError(err error) *string {
+	message := strings.ToValidUTF8(err.Error(), string(utf8.RuneError))
+	if len(message) > webhookErrorMaxLen {
+		cut := webhookErrorMaxLen
+		for cut > 0 && !utf8.RuneStart(message[cut]) {
+			cut--
+		}
+		message = message[:cut]
+	}
+
+	return &message
+}
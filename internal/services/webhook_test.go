@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/apperr"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+)
+
+const testWebhookSecret = "secret"
+
+// fakeWebhookStorage keeps a single webhook and disables it the way the
+// postgres storage does, after disableAfter failed deliveries in a row.
+type fakeWebhookStorage struct {
+	WebhookStorageProvider
+
+	mu         sync.Mutex
+	webhook    models.Webhook
+	deliveries []models.WebhookDelivery
+	results    []bool
+}
+
+func (s *fakeWebhookStorage) CreateWebhook(_ context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhook = *webhook
+	s.webhook.ID = 1
+
+	return &s.webhook, nil
+}
+
+func (s *fakeWebhookStorage) ListActiveWebhooks(context.Context, []int) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.webhook.Enabled {
+		return nil, nil
+	}
+
+	return []models.Webhook{s.webhook}, nil
+}
+
+func (s *fakeWebhookStorage) RecordWebhookResult(_ context.Context, id int, success bool, disableAfter int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results = append(s.results, success)
+
+	if success {
+		s.webhook.FailureCount = 0
+	} else {
+		s.webhook.FailureCount++
+		if s.webhook.FailureCount >= disableAfter {
+			s.webhook.Enabled = false
+		}
+	}
+
+	return s.webhook.Enabled, nil
+}
+
+func (s *fakeWebhookStorage) SaveWebhookDelivery(_ context.Context, delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries = append(s.deliveries, *delivery)
+
+	return nil
+}
+
+// receiver answers with the given statuses in turn, repeating the last one.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+
+	status := r.statuses[min(len(r.requests), len(r.statuses))-1]
+	if status >= 300 && status < 400 {
+		w.Header().Set("Location", "/elsewhere")
+	}
+	w.WriteHeader(status)
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.requests)
+}
+
+func testWebhookConfig() config.Webhooks {
+	return config.Webhooks{
+		Timeout:      time.Second,
+		QueueSize:    10,
+		MaxAttempts:  3,
+		BaseBackoff:  time.Millisecond,
+		MaxBackoff:   time.Millisecond,
+		DisableAfter: 10,
+		AllowPrivate: true,
+	}
+}
+
+func newTestWebhookService(t *testing.T, cfg config.Webhooks, statuses ...int) (*WebhookService, *fakeWebhookStorage, *receiver) {
+	t.Helper()
+
+	rec := &receiver{statuses: statuses}
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+
+	provider := &fakeWebhookStorage{}
+	service := NewWebhookService(slog.New(slog.NewTextHandler(io.Discard, nil)), provider, cfg)
+
+	enabled := true
+	if _, err := service.CreateWebhook(context.Background(), &models.WebhookRequest{
+		ProjectID: 1,
+		URL:       server.URL + "/hook",
+		Secret:    testWebhookSecret,
+		Enabled:   &enabled,
+	}); err != nil {
+		t.Fatalf("couldn't create webhook: %v", err)
+	}
+
+	return service, provider, rec
+}
+
+// publish queues an event and delivers everything queued, as a worker of Run
+// would, until no retry has come back for a while.
+func publish(service *WebhookService) {
+	service.Publish([]models.GoodLog{{ID: 7, ProjectID: 1, Name: "good", Event: models.EventCreate}})
+
+	for {
+		select {
+		case job := <-service.jobs:
+			service.deliver(context.Background(), &job)
+		case <-time.After(100 * time.Millisecond):
+			return
+		}
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	service, _, rec := newTestWebhookService(t, testWebhookConfig(), http.StatusOK)
+
+	publish(service)
+
+	if rec.count() != 1 {
+		t.Fatalf("got %d requests, want 1", rec.count())
+	}
+
+	req, body := rec.requests[0], rec.bodies[0]
+	timestamp := req.Header.Get(WebhookTimestampHeader)
+
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get(WebhookSignatureHeader); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+
+	var event models.FeedEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("couldn't decode body %s: %v", body, err)
+	}
+
+	if event.Good.ID != 7 || event.Event != models.EventCreate {
+		t.Errorf("got event %+v", event)
+	}
+	if req.Header.Get(WebhookIDHeader) != event.ID || req.Header.Get(WebhookEventHeader) != event.Event {
+		t.Errorf("headers %v don't match event %+v", req.Header, event)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+		success  bool
+	}{
+		{name: "success", statuses: []int{200}, attempts: 1, success: true},
+		{name: "server error then success", statuses: []int{500, 502, 200}, attempts: 3, success: true},
+		{name: "rate limited then success", statuses: []int{429, 204}, attempts: 2, success: true},
+		{name: "server errors", statuses: []int{503}, attempts: 3},
+		{name: "bad request", statuses: []int{400}, attempts: 1},
+		{name: "not found", statuses: []int{404}, attempts: 1},
+		{name: "redirect", statuses: []int{302}, attempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, provider, rec := newTestWebhookService(t, testWebhookConfig(), tt.statuses...)
+
+			publish(service)
+
+			if rec.count() != tt.attempts {
+				t.Fatalf("got %d requests, want %d", rec.count(), tt.attempts)
+			}
+
+			if len(provider.deliveries) != tt.attempts {
+				t.Fatalf("got %d delivery rows, want %d", len(provider.deliveries), tt.attempts)
+			}
+
+			for i, delivery := range provider.deliveries {
+				status := tt.statuses[min(i, len(tt.statuses)-1)]
+				last := i == len(provider.deliveries)-1
+
+				if delivery.WebhookID != 1 || delivery.Attempt != i+1 || delivery.Event != models.EventCreate {
+					t.Errorf("delivery %d is %+v", i, delivery)
+				}
+				if delivery.StatusCode == nil || *delivery.StatusCode != status {
+					t.Errorf("delivery %d has status %v, want %d", i, delivery.StatusCode, status)
+				}
+				if delivery.Success != (last && tt.success) {
+					t.Errorf("delivery %d success is %t", i, delivery.Success)
+				}
+			}
+
+			if len(provider.results) != 1 || provider.results[0] != tt.success {
+				t.Errorf("recorded results %v, want [%t]", provider.results, tt.success)
+			}
+		})
+	}
+}
+
+func TestWebhookRedirectIsNotFollowed(t *testing.T) {
+	service, _, rec := newTestWebhookService(t, testWebhookConfig(), http.StatusFound)
+
+	publish(service)
+
+	if rec.count() != 1 || rec.requests[0].URL.Path != "/hook" {
+		t.Fatalf("redirect was followed: %d requests", rec.count())
+	}
+}
+
+func TestWebhookDisabledAfterFailures(t *testing.T) {
+	cfg := testWebhookConfig()
+	cfg.MaxAttempts = 2
+	cfg.DisableAfter = 3
+
+	service, provider, rec := newTestWebhookService(t, cfg, http.StatusInternalServerError)
+
+	for i := 0; i < cfg.DisableAfter+2; i++ {
+		publish(service)
+	}
+
+	if want := cfg.DisableAfter * cfg.MaxAttempts; rec.count() != want {
+		t.Errorf("got %d requests, want %d", rec.count(), want)
+	}
+
+	if provider.webhook.Enabled {
+		t.Error("webhook is still enabled")
+	}
+
+	if len(provider.results) != cfg.DisableAfter {
+		t.Errorf("recorded %d results, want %d", len(provider.results), cfg.DisableAfter)
+	}
+}
+
+func TestWebhookPrivateAddresses(t *testing.T) {
+	cfg := testWebhookConfig()
+	cfg.AllowPrivate = false
+
+	service := NewWebhookService(slog.New(slog.NewTextHandler(io.Discard, nil)), &fakeWebhookStorage{}, cfg)
+
+	urls := []struct {
+		url     string
+		allowed bool
+	}{
+		{url: "http://127.0.0.1:8080/hook"},
+		{url: "http://localhost/hook"},
+		{url: "http://[::1]/hook"},
+		{url: "http://10.1.2.3/hook"},
+		{url: "http://192.168.0.1/hook"},
+		{url: "http://169.254.169.254/latest/meta-data"},
+		{url: "http://0.0.0.0/hook"},
+		{url: "http://0.1.2.3/hook"},
+		{url: "http://100.64.1.1/hook"},
+		{url: "http://198.18.0.1/hook"},
+		{url: "http://[64:ff9b::a00:1]/hook"},
+		{url: "http://[::ffff:127.0.0.1]/hook"},
+		{url: "http://[fd00::1]/hook"},
+		{url: "https://93.184.216.34/hook", allowed: true},
+	}
+
+	for _, tt := range urls {
+		_, err := service.CreateWebhook(context.Background(), &models.WebhookRequest{ProjectID: 1, URL: tt.url})
+
+		if tt.allowed {
+			if err != nil {
+				t.Errorf("%s: %v", tt.url, err)
+			}
+			continue
+		}
+
+		var appErr *apperr.Error
+		if !errors.As(err, &appErr) || appErr.Kind != apperr.Validation {
+			t.Errorf("%s: got %v, want a validation error", tt.url, err)
+		}
+	}
+}
+
+func TestWebhookDeliveryToPrivateAddress(t *testing.T) {
+	rec := &receiver{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	cfg := testWebhookConfig()
+	cfg.AllowPrivate = false
+	cfg.MaxAttempts = 1
+
+	provider := &fakeWebhookStorage{}
+	service := NewWebhookService(slog.New(slog.NewTextHandler(io.Discard, nil)), provider, cfg)
+
+	// stored before the check existed, or a name resolving to a private address
+	provider.webhook = models.Webhook{ID: 1, ProjectID: 1, URL: server.URL, Enabled: true}
+
+	publish(service)
+
+	if rec.count() != 0 {
+		t.Fatalf("receiver got %d requests", rec.count())
+	}
+
+	if len(provider.deliveries) != 1 || provider.deliveries[0].Success || provider.deliveries[0].Error == nil {
+		t.Errorf("got deliveries %+v, want one failure", provider.deliveries)
+	}
+}
+
+func TestWebhookRetryDoesNotHoldWorker(t *testing.T) {
+	cfg := testWebhookConfig()
+	cfg.BaseBackoff = time.Hour
+	cfg.MaxBackoff = time.Hour
+
+	service, provider, rec := newTestWebhookService(t, cfg, http.StatusServiceUnavailable)
+
+	service.Publish([]models.GoodLog{{ID: 7, ProjectID: 1, Event: models.EventCreate}})
+	job := <-service.jobs
+
+	done := make(chan struct{})
+	go func() {
+		service.deliver(context.Background(), &job)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker is waiting for the retry backoff")
+	}
+
+	if rec.count() != 1 || len(provider.deliveries) != 1 {
+		t.Errorf("got %d requests and %d delivery rows, want 1 and 1", rec.count(), len(provider.deliveries))
+	}
+	if len(provider.results) != 0 {
+		t.Errorf("result recorded before the last attempt: %v", provider.results)
+	}
+}
+
+func TestWebhookQueueFull(t *testing.T) {
+	cfg := testWebhookConfig()
+	cfg.QueueSize = 1
+
+	service, provider, rec := newTestWebhookService(t, cfg, http.StatusOK)
+
+	service.Publish([]models.GoodLog{
+		{ID: 7, ProjectID: 1, Event: models.EventCreate},
+		{ID: 8, ProjectID: 1, Event: models.EventCreate},
+	})
+
+	if rec.count() != 0 {
+		t.Fatalf("receiver got %d requests", rec.count())
+	}
+
+	if len(provider.deliveries) != 1 {
+		t.Fatalf("got %d delivery rows, want one for the dropped event", len(provider.deliveries))
+	}
+
+	dropped := provider.deliveries[0]
+	if dropped.Success || dropped.Attempt != 1 || dropped.Error == nil || dropped.WebhookID != 1 {
+		t.Errorf("dropped event logged as %+v", dropped)
+	}
+}
+
+func TestDeliveryErrorTruncation(t *testing.T) {
+	message := strings.Repeat("a", webhookErrorMaxLen-1) + "яя"
+
+	got := *deliveryError(errors.New(message))
+
+	if !utf8.ValidString(got) {
+		t.Errorf("truncated message isn't valid UTF-8")
+	}
+	if want := strings.Repeat("a", webhookErrorMaxLen-1); got != want {
+		t.Errorf("got %d bytes, want %d", len(got), len(want))
+	}
+
+	if got := *deliveryError(errors.New("bad \xff byte")); !utf8.ValidString(got) {
+		t.Errorf("message %q isn't valid UTF-8", got)
+	}
+}
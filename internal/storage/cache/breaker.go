@@ -0,0 +1,264 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/lib/breaker"
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	recoveryTimeout = 30 * time.Second
+	recoveryBatch   = 1000
+)
+
+// BreakerCache guards the goods cache with a circuit breaker. Once Redis keeps
+// failing, calls fail at once with breaker.ErrOpen and the services go
+// straight to storage instead of waiting for timeouts. Run probes Redis while
+// the breaker is open and closes it when Redis answers again, after dropping
+// the entries the skipped writes left stale.
+//
+// Locks and idempotency records go through the breaker too: the retention
+// worker skips a run and the idempotency middleware processes the request as
+// is, so failing fast spares them the timeouts as well.
+type BreakerCache struct {
+	*Cache
+	log     *slog.Logger
+	breaker *breaker.Breaker
+	cfg     config.CacheBreaker
+}
+
+func NewBreakerCache(log *slog.Logger, cache *Cache, cfg config.CacheBreaker) *BreakerCache {
+	return &BreakerCache{
+		Cache:   cache,
+		log:     log,
+		breaker: breaker.New(cfg.Threshold),
+		cfg:     cfg,
+	}
+}
+
+func (c *BreakerCache) BreakerStats() breaker.Stats {
+	return c.breaker.Stats()
+}
+
+// Run probes Redis every probe interval while the breaker is open.
+func (c *BreakerCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if c.breaker.State() == breaker.Open {
+				c.probe(ctx)
+			}
+		}
+	}
+}
+
+func (c *BreakerCache) probe(ctx context.Context) {
+	const op = "storage.cache.probe"
+
+	log := c.log.With(slog.String("op", op))
+
+	pingCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	if err := c.client.Ping(pingCtx).Err(); err != nil {
+		log.Debug("cache is still unreachable", slog.String("error", err.Error()))
+		return
+	}
+
+	cleanupCtx, cancelCleanup := context.WithTimeout(ctx, recoveryTimeout)
+	defer cancelCleanup()
+
+	if err := c.dropStale(cleanupCtx); err != nil {
+		log.Debug("couldn't drop stale cache entries", slog.String("error", err.Error()))
+		return
+	}
+
+	if c.breaker.Reset() {
+		log.Info("cache is reachable again, circuit breaker closed")
+	}
+}
+
+// dropStale deletes what the writes skipped while the breaker was open left
+// out of date: the maximum priority, the goods and the suggestion indexes,
+// which have no expiry. They are rebuilt from storage on the next reads.
+func (c *BreakerCache) dropStale(ctx context.Context) error {
+	keys := []string{priorityKey}
+
+	for _, pattern := range []string{goodKeyPattern, suggestKeyPattern} {
+		iter := c.client.Scan(ctx, 0, pattern, recoveryBatch).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+	}
+
+	for start := 0; start < len(keys); start += recoveryBatch {
+		end := min(start+recoveryBatch, len(keys))
+		if err := c.client.Del(ctx, keys[start:end]...).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// do runs fn with the call timeout. Misses and calls cancelled by the caller
+// don't count as failures.
+func (c *BreakerCache) do(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	if !c.breaker.Allow() {
+		return wrapper.Wrap(op, breaker.ErrOpen)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	err := fn(callCtx)
+
+	switch {
+	case err == nil || errors.Is(err, redis.Nil):
+		c.breaker.Success()
+	case ctx.Err() != nil:
+	default:
+		if c.breaker.Failure() {
+			c.log.Warn("cache keeps failing, circuit breaker opened", slog.String("op", op), slog.String("error", err.Error()))
+		}
+	}
+
+	return err
+}
+
+func (c *BreakerCache) GetMaxPriority(ctx context.Context) (value string, err error) {
+	err = c.do(ctx, "storage.cache.breaker.GetMaxPriority", func(ctx context.Context) error {
+		value, err = c.Cache.GetMaxPriority(ctx)
+		return err
+	})
+
+	return value, err
+}
+
+func (c *BreakerCache) SetMaxPriority(ctx context.Context, priority int) error {
+	return c.do(ctx, "storage.cache.breaker.SetMaxPriority", func(ctx context.Context) error {
+		return c.Cache.SetMaxPriority(ctx, priority)
+	})
+}
+
+func (c *BreakerCache) SaveGood(ctx context.Context, key string, value *models.GoodCache) error {
+	return c.do(ctx, "storage.cache.breaker.SaveGood", func(ctx context.Context) error {
+		return c.Cache.SaveGood(ctx, key, value)
+	})
+}
+
+func (c *BreakerCache) GetGood(ctx context.Context, key string) (value string, err error) {
+	err = c.do(ctx, "storage.cache.breaker.GetGood", func(ctx context.Context) error {
+		value, err = c.Cache.GetGood(ctx, key)
+		return err
+	})
+
+	return value, err
+}
+
+func (c *BreakerCache) DeleteGood(ctx context.Context, key string) error {
+	return c.do(ctx, "storage.cache.breaker.DeleteGood", func(ctx context.Context) error {
+		return c.Cache.DeleteGood(ctx, key)
+	})
+}
+
+func (c *BreakerCache) SaveSuggestion(ctx context.Context, good *models.Good) error {
+	return c.do(ctx, "storage.cache.breaker.SaveSuggestion", func(ctx context.Context) error {
+		return c.Cache.SaveSuggestion(ctx, good)
+	})
+}
+
+func (c *BreakerCache) DeleteSuggestion(ctx context.Context, projectID, id int) error {
+	return c.do(ctx, "storage.cache.breaker.DeleteSuggestion", func(ctx context.Context) error {
+		return c.Cache.DeleteSuggestion(ctx, projectID, id)
+	})
+}
+
+func (c *BreakerCache) GetSuggestions(ctx context.Context, projectID int, prefix string, limit int) (value *[]models.Suggestion, err error) {
+	err = c.do(ctx, "storage.cache.breaker.GetSuggestions", func(ctx context.Context) error {
+		value, err = c.Cache.GetSuggestions(ctx, projectID, prefix, limit)
+		return err
+	})
+
+	return value, err
+}
+
+func (c *BreakerCache) SaveGoods(ctx context.Context, values map[string]*models.GoodCache) error {
+	return c.do(ctx, "storage.cache.breaker.SaveGoods", func(ctx context.Context) error {
+		return c.Cache.SaveGoods(ctx, values)
+	})
+}
+
+func (c *BreakerCache) DeleteGoods(ctx context.Context, keys []string) error {
+	return c.do(ctx, "storage.cache.breaker.DeleteGoods", func(ctx context.Context) error {
+		return c.Cache.DeleteGoods(ctx, keys)
+	})
+}
+
+func (c *BreakerCache) SaveSuggestions(ctx context.Context, goods *[]models.Good) error {
+	return c.do(ctx, "storage.cache.breaker.SaveSuggestions", func(ctx context.Context) error {
+		return c.Cache.SaveSuggestions(ctx, goods)
+	})
+}
+
+func (c *BreakerCache) DeleteSuggestions(ctx context.Context, goods *[]models.Good) error {
+	return c.do(ctx, "storage.cache.breaker.DeleteSuggestions", func(ctx context.Context) error {
+		return c.Cache.DeleteSuggestions(ctx, goods)
+	})
+}
+
+func (c *BreakerCache) AcquireLock(ctx context.Context, name string, ttl time.Duration) (token string, ok bool, err error) {
+	err = c.do(ctx, "storage.cache.breaker.AcquireLock", func(ctx context.Context) error {
+		token, ok, err = c.Cache.AcquireLock(ctx, name, ttl)
+		return err
+	})
+
+	return token, ok, err
+}
+
+func (c *BreakerCache) ReleaseLock(ctx context.Context, name, token string) error {
+	return c.do(ctx, "storage.cache.breaker.ReleaseLock", func(ctx context.Context) error {
+		return c.Cache.ReleaseLock(ctx, name, token)
+	})
+}
+
+func (c *BreakerCache) StartIdempotent(
+	ctx context.Context,
+	key string,
+	record *models.IdempotencyRecord,
+	ttl time.Duration,
+) (stored *models.IdempotencyRecord, started bool, err error) {
+	err = c.do(ctx, "storage.cache.breaker.StartIdempotent", func(ctx context.Context) error {
+		stored, started, err = c.Cache.StartIdempotent(ctx, key, record, ttl)
+		return err
+	})
+
+	return stored, started, err
+}
+
+func (c *BreakerCache) SaveIdempotent(ctx context.Context, key string, record *models.IdempotencyRecord, ttl time.Duration) error {
+	return c.do(ctx, "storage.cache.breaker.SaveIdempotent", func(ctx context.Context) error {
+		return c.Cache.SaveIdempotent(ctx, key, record, ttl)
+	})
+}
+
+func (c *BreakerCache) DeleteIdempotent(ctx context.Context, key string) error {
+	return c.do(ctx, "storage.cache.breaker.DeleteIdempotent", func(ctx context.Context) error {
+		return c.Cache.DeleteIdempotent(ctx, key)
+	})
+}
@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IskanderSh/hezzl-task/internal/config"
+	"github.com/IskanderSh/hezzl-task/internal/lib/breaker"
+)
+
+// fakeRedis speaks just enough RESP2 for the breaker: while down it answers
+// every command with an error.
+type fakeRedis struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	down  bool
+	calls int
+	keys  map[string]string
+}
+
+func newFakeRedis(t *testing.T, keys ...string) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &fakeRedis{listener: listener, keys: make(map[string]string)}
+	for _, key := range keys {
+		server.keys[key] = "value"
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (r *fakeRedis) config() config.Cache {
+	addr := r.listener.Addr().(*net.TCPAddr)
+
+	return config.Cache{Host: addr.IP.String(), Port: addr.Port}
+}
+
+func (r *fakeRedis) setDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.down = down
+}
+
+func (r *fakeRedis) callCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.calls
+}
+
+func (r *fakeRedis) keyNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.keys))
+	for key := range r.keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		if _, err := io.WriteString(conn, r.reply(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+
+	return args, nil
+}
+
+func (r *fakeRedis) reply(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	command := strings.ToUpper(args[0])
+
+	// the connection handshake falls back to RESP2 on these errors
+	if command == "HELLO" || command == "CLIENT" {
+		return "-ERR unknown command\r\n"
+	}
+
+	r.calls++
+
+	if r.down {
+		return "-ERR server is down\r\n"
+	}
+
+	switch command {
+	case "PING":
+		return "+PONG\r\n"
+	case "SET":
+		r.keys[args[1]] = args[2]
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := r.keys[key]; ok {
+				delete(r.keys, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "SCAN":
+		var matched []string
+		for key := range r.keys {
+			if ok, _ := path.Match(args[3], key); ok {
+				matched = append(matched, key)
+			}
+		}
+
+		reply := fmt.Sprintf("*2\r\n$1\r\n0\r\n*%d\r\n", len(matched))
+		for _, key := range matched {
+			reply += fmt.Sprintf("$%d\r\n%s\r\n", len(key), key)
+		}
+		return reply
+	default:
+		return fmt.Sprintf("-ERR unknown command %s\r\n", command)
+	}
+}
+
+func TestBreakerCacheRecovery(t *testing.T) {
+	server := newFakeRedis(t,
+		priorityKey, "12", "suggest:7", "suggest:7:members", "lock:retention", "idempotency:key")
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cache := NewCache(log, server.config())
+	t.Cleanup(func() { _ = cache.Close() })
+
+	guarded := NewBreakerCache(log, cache, config.CacheBreaker{
+		Threshold:     2,
+		Timeout:       time.Second,
+		ProbeInterval: time.Hour,
+	})
+
+	ctx := context.Background()
+
+	server.setDown(true)
+
+	for i := 0; i < 2; i++ {
+		if err := guarded.DeleteGood(ctx, "12"); err == nil {
+			t.Fatal("call to a failing cache succeeded")
+		}
+	}
+
+	if guarded.breaker.State() != breaker.Open {
+		t.Fatal("breaker didn't open after the threshold")
+	}
+
+	calls := server.callCount()
+
+	if err := guarded.DeleteSuggestion(ctx, 7, 12); !errors.Is(err, breaker.ErrOpen) {
+		t.Errorf("got %v, want %v", err, breaker.ErrOpen)
+	}
+	if _, _, err := guarded.AcquireLock(ctx, "retention", time.Minute); !errors.Is(err, breaker.ErrOpen) {
+		t.Errorf("lock: got %v, want %v", err, breaker.ErrOpen)
+	}
+
+	if server.callCount() != calls {
+		t.Error("open breaker let calls through to the cache")
+	}
+
+	guarded.probe(ctx)
+
+	if guarded.breaker.State() != breaker.Open {
+		t.Fatal("breaker closed while the cache is still failing")
+	}
+
+	server.setDown(false)
+	guarded.probe(ctx)
+
+	if guarded.breaker.State() != breaker.Closed {
+		t.Fatal("breaker didn't close once the cache answered")
+	}
+
+	// everything the skipped writes could have left stale is gone
+	want := []string{"idempotency:key", "lock:retention"}
+	if got := server.keyNames(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("keys after recovery %v, want %v", got, want)
+	}
+
+	if err := guarded.DeleteGood(ctx, "13"); err != nil {
+		t.Errorf("call after recovery: %v", err)
+	}
+}
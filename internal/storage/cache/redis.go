@@ -2,8 +2,14 @@ package redis
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/IskanderSh/hezzl-task/internal/config"
 	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
@@ -14,7 +20,49 @@ import (
 const (
 	priorityKey      = "priority"
 	zeroExpiration   = 0
-	minuteExpiration = 60
+	minuteExpiration = time.Minute
+
+	suggestKey        = "suggest:%d"
+	suggestMembersKey = "suggest:%d:members"
+	suggestSeparator  = "\x00"
+	suggestLexMax     = "\xff"
+
+	// goods are cached under their bare id
+	goodKeyPattern    = "[0-9]*"
+	suggestKeyPattern = "suggest:*"
+
+	lockKey        = "lock:%s"
+	idempotencyKey = "idempotency:%s"
+	lockTokenSize  = 16
+)
+
+// Suggestions are kept in a per-project sorted set with equal scores, so members
+// are ordered lexicographically and can be looked up by prefix with ZRANGEBYLEX.
+// A member is "<lower name>\x00<name>\x00<id>"; a hash maps id to its current
+// member, so renames and deletes can drop the stale entry.
+var (
+	saveSuggestionScript = redis.NewScript(`
+local old = redis.call('HGET', KEYS[2], ARGV[1])
+if old then
+	redis.call('ZREM', KEYS[1], old)
+end
+redis.call('ZADD', KEYS[1], 0, ARGV[2])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+return 1`)
+
+	releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+	deleteSuggestionScript = redis.NewScript(`
+local old = redis.call('HGET', KEYS[2], ARGV[1])
+if old then
+	redis.call('ZREM', KEYS[1], old)
+	redis.call('HDEL', KEYS[2], ARGV[1])
+end
+return 1`)
 )
 
 type Cache struct {
@@ -22,9 +70,9 @@ type Cache struct {
 	client *redis.Client
 }
 
-func NewCache(ctx context.Context, log *slog.Logger, cfg config.Cache) (*Cache, error) {
-	const op = "storage.cache.NewCache"
-
+// NewCache doesn't wait for Redis: the client connects on first use, so Ping
+// tells whether it is reachable.
+func NewCache(log *slog.Logger, cfg config.Cache) *Cache {
 	connString := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 	log.Debug(fmt.Sprintf("connection string for cache: %s", connString))
 
@@ -32,11 +80,27 @@ func NewCache(ctx context.Context, log *slog.Logger, cfg config.Cache) (*Cache,
 		Addr: connString,
 	})
 
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, wrapper.Wrap(op, err)
+	return &Cache{log: log, client: client}
+}
+
+func (c *Cache) Close() error {
+	const op = "storage.cache.Close"
+
+	if err := c.client.Close(); err != nil {
+		return wrapper.Wrap(op, err)
 	}
 
-	return &Cache{log: log, client: client}, nil
+	return nil
+}
+
+func (c *Cache) Ping(ctx context.Context) error {
+	const op = "storage.cache.Ping"
+
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
 }
 
 func (c *Cache) GetMaxPriority(ctx context.Context) (string, error) {
@@ -95,3 +159,219 @@ func (c *Cache) DeleteGood(ctx context.Context, key string) error {
 
 	return nil
 }
+
+func (c *Cache) SaveSuggestion(ctx context.Context, good *models.Good) error {
+	const op = "storage.cache.SaveSuggestion"
+
+	keys := []string{fmt.Sprintf(suggestKey, good.ProjectID), fmt.Sprintf(suggestMembersKey, good.ProjectID)}
+	member := strings.Join([]string{strings.ToLower(good.Name), good.Name, strconv.Itoa(good.ID)}, suggestSeparator)
+
+	if err := saveSuggestionScript.Run(ctx, c.client, keys, good.ID, member).Err(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) DeleteSuggestion(ctx context.Context, projectID, id int) error {
+	const op = "storage.cache.DeleteSuggestion"
+
+	keys := []string{fmt.Sprintf(suggestKey, projectID), fmt.Sprintf(suggestMembersKey, projectID)}
+
+	if err := deleteSuggestionScript.Run(ctx, c.client, keys, id).Err(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) GetSuggestions(ctx context.Context, projectID int, prefix string, limit int) (*[]models.Suggestion, error) {
+	const op = "storage.cache.GetSuggestions"
+
+	prefix = strings.ToLower(prefix)
+
+	members, err := c.client.ZRangeByLex(ctx, fmt.Sprintf(suggestKey, projectID), &redis.ZRangeBy{
+		Min:   "[" + prefix,
+		Max:   "[" + prefix + suggestLexMax,
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	suggestions := make([]models.Suggestion, 0, len(members))
+
+	for _, member := range members {
+		parts := strings.Split(member, suggestSeparator)
+		if len(parts) != 3 {
+			c.log.Warn(fmt.Sprintf("invalid suggestion member in project %d", projectID))
+			continue
+		}
+
+		id, err := strconv.Atoi(parts[2])
+		if err != nil {
+			c.log.Warn(fmt.Sprintf("invalid suggestion id in project %d", projectID))
+			continue
+		}
+
+		suggestions = append(suggestions, models.Suggestion{ID: id, Name: parts[1], Score: 1})
+	}
+
+	return &suggestions, nil
+}
+
+// AcquireLock takes a named lock for ttl. It returns the token needed to
+// release the lock, or false when another holder already owns it.
+func (c *Cache) AcquireLock(ctx context.Context, name string, ttl time.Duration) (string, bool, error) {
+	const op = "storage.cache.AcquireLock"
+
+	buf := make([]byte, lockTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, wrapper.Wrap(op, err)
+	}
+	token := hex.EncodeToString(buf)
+
+	ok, err := c.client.SetNX(ctx, fmt.Sprintf(lockKey, name), token, ttl).Result()
+	if err != nil {
+		return "", false, wrapper.Wrap(op, err)
+	}
+
+	return token, ok, nil
+}
+
+// ReleaseLock drops the lock only if it is still held with the given token.
+func (c *Cache) ReleaseLock(ctx context.Context, name, token string) error {
+	const op = "storage.cache.ReleaseLock"
+
+	if err := releaseLockScript.Run(ctx, c.client, []string{fmt.Sprintf(lockKey, name)}, token).Err(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// StartIdempotent stores record under key unless the key is already taken.
+// It returns true when the caller owns the key, otherwise the stored record.
+func (c *Cache) StartIdempotent(
+	ctx context.Context,
+	key string,
+	record *models.IdempotencyRecord,
+	ttl time.Duration,
+) (*models.IdempotencyRecord, bool, error) {
+	const op = "storage.cache.StartIdempotent"
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, wrapper.Wrap(op, err)
+	}
+
+	ok, err := c.client.SetNX(ctx, fmt.Sprintf(idempotencyKey, key), data, ttl).Result()
+	if err != nil {
+		return nil, false, wrapper.Wrap(op, err)
+	}
+
+	if ok {
+		return record, true, nil
+	}
+
+	value, err := c.client.Get(ctx, fmt.Sprintf(idempotencyKey, key)).Bytes()
+	if err != nil {
+		return nil, false, wrapper.Wrap(op, err)
+	}
+
+	stored := models.IdempotencyRecord{}
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return nil, false, wrapper.Wrap(op, err)
+	}
+
+	return &stored, false, nil
+}
+
+func (c *Cache) SaveIdempotent(ctx context.Context, key string, record *models.IdempotencyRecord, ttl time.Duration) error {
+	const op = "storage.cache.SaveIdempotent"
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	if err := c.client.Set(ctx, fmt.Sprintf(idempotencyKey, key), data, ttl).Err(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) DeleteIdempotent(ctx context.Context, key string) error {
+	const op = "storage.cache.DeleteIdempotent"
+
+	if err := c.client.Del(ctx, fmt.Sprintf(idempotencyKey, key)).Err(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) SaveGoods(ctx context.Context, values map[string]*models.GoodCache) error {
+	const op = "storage.cache.SaveGoods"
+
+	pipe := c.client.Pipeline()
+	for key, value := range values {
+		pipe.Set(ctx, key, value, minuteExpiration)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) DeleteGoods(ctx context.Context, keys []string) error {
+	const op = "storage.cache.DeleteGoods"
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := c.client.Del(ctx, keys...).Err(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) SaveSuggestions(ctx context.Context, goods *[]models.Good) error {
+	const op = "storage.cache.SaveSuggestions"
+
+	pipe := c.client.Pipeline()
+	for _, good := range *goods {
+		keys := []string{fmt.Sprintf(suggestKey, good.ProjectID), fmt.Sprintf(suggestMembersKey, good.ProjectID)}
+		member := strings.Join([]string{strings.ToLower(good.Name), good.Name, strconv.Itoa(good.ID)}, suggestSeparator)
+
+		saveSuggestionScript.Eval(ctx, pipe, keys, good.ID, member)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) DeleteSuggestions(ctx context.Context, goods *[]models.Good) error {
+	const op = "storage.cache.DeleteSuggestions"
+
+	pipe := c.client.Pipeline()
+	for _, good := range *goods {
+		keys := []string{fmt.Sprintf(suggestKey, good.ProjectID), fmt.Sprintf(suggestMembersKey, good.ProjectID)}
+
+		deleteSuggestionScript.Eval(ctx, pipe, keys, good.ID)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
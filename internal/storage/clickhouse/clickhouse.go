@@ -2,22 +2,36 @@ package clickhouse
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"strings"
+	"sync/atomic"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/IskanderSh/hezzl-task/internal/config"
 	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
 	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/IskanderSh/hezzl-task/migrations"
 )
 
+var (
+	ErrUnavailable       = errors.New("log storage is not connected yet")
+	ErrMigrationsPending = errors.New("migrations are not applied")
+)
+
+// LogStorage refuses queries with ErrUnavailable until Connect succeeds, so
+// the application can start without ClickHouse.
 type LogStorage struct {
 	log        *slog.Logger
 	connection driver.Conn
+	connected  atomic.Bool
 }
 
-func NewLogStorage(ctx context.Context, log *slog.Logger, cfg config.LogStorage) (*LogStorage, error) {
+// NewLogStorage doesn't reach the server, the connections are opened lazily.
+func NewLogStorage(log *slog.Logger, cfg config.LogStorage) (*LogStorage, error) {
 	const op = "storage.clickhouse.NewLogStorage"
 
 	conn, err := clickhouse.Open(&clickhouse.Options{
@@ -32,24 +46,99 @@ func NewLogStorage(ctx context.Context, log *slog.Logger, cfg config.LogStorage)
 		return nil, wrapper.Wrap(op, err)
 	}
 
-	if err := conn.Ping(ctx); err != nil {
-		if exception, ok := err.(*clickhouse.Exception); ok {
-			log.Debug(fmt.Sprintf("Exception [%d] %s \n%s\n", exception.Code, exception.Message, exception.StackTrace))
-		}
-		return nil, wrapper.Wrap(op, err)
-	}
-
 	return &LogStorage{
 		log:        log,
 		connection: conn,
 	}, nil
 }
 
+// Connect pings the server and starts accepting queries once it answers.
+func (s *LogStorage) Connect(ctx context.Context) error {
+	const op = "storage.clickhouse.Connect"
+
+	if err := s.connection.Ping(ctx); err != nil {
+		if exception, ok := err.(*clickhouse.Exception); ok {
+			s.log.Debug(fmt.Sprintf("Exception [%d] %s \n%s\n", exception.Code, exception.Message, exception.StackTrace))
+		}
+		return wrapper.Wrap(op, err)
+	}
+
+	s.connected.Store(true)
+
+	return nil
+}
+
+// Ping checks the server without changing whether queries are accepted.
+func (s *LogStorage) Ping(ctx context.Context) error {
+	const op = "storage.clickhouse.Ping"
+
+	if err := s.connection.Ping(ctx); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// CheckMigrations compares the goose migrations in fsys with the versions
+// goose recorded in ClickHouse and returns ErrMigrationsPending listing the
+// missing ones.
+func (s *LogStorage) CheckMigrations(ctx context.Context, fsys fs.FS) error {
+	const op = "storage.clickhouse.CheckMigrations"
+
+	rows, err := s.connection.Query(ctx, getAppliedMigrations)
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+	defer rows.Close()
+
+	var applied []int64
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return wrapper.Wrap(op, err)
+		}
+		applied = append(applied, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	pending, err := migrations.Pending(fsys, applied)
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	if len(pending) > 0 {
+		return wrapper.Wrap(op, fmt.Errorf("%w: %s", ErrMigrationsPending, strings.Join(pending, ", ")))
+	}
+
+	return nil
+}
+
+func (s *LogStorage) Connected() bool {
+	return s.connected.Load()
+}
+
+func (s *LogStorage) Close() error {
+	const op = "storage.clickhouse.Close"
+
+	if err := s.connection.Close(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
 func (s *LogStorage) NewLogs(ctx context.Context, logs *[]models.GoodLog) error {
 	const op = "storage.clickhouse.NewLogs"
 
 	log := s.log.With(slog.String("op", op))
 
+	if !s.Connected() {
+		return wrapper.Wrap(op, ErrUnavailable)
+	}
+
 	batch, err := s.connection.PrepareBatch(ctx, insertQuery)
 	if err != nil {
 		return wrapper.Wrap(op, err)
@@ -64,9 +153,10 @@ func (s *LogStorage) NewLogs(ctx context.Context, logs *[]models.GoodLog) error
 			value.Priority,
 			value.Removed,
 			value.EventTime,
+			value.Event,
 		)
 		if err != nil {
-			log.Warn("error when inserting log to clickhouse", value.ID)
+			log.Warn("error when inserting log to clickhouse", slog.Int("id", value.ID))
 		}
 	}
 
@@ -77,3 +167,51 @@ func (s *LogStorage) NewLogs(ctx context.Context, logs *[]models.GoodLog) error
 
 	return nil
 }
+
+// GetGoodsHistory returns up to limit latest events for each of the goods.
+func (s *LogStorage) GetGoodsHistory(ctx context.Context, keys []models.GoodKey, limit int) (map[models.GoodKey][]models.GoodLog, error) {
+	const op = "storage.clickhouse.GetGoodsHistory"
+
+	res := make(map[models.GoodKey][]models.GoodLog, len(keys))
+	if len(keys) == 0 {
+		return res, nil
+	}
+
+	if !s.Connected() {
+		return nil, wrapper.Wrap(op, ErrUnavailable)
+	}
+
+	tuples := make([]clickhouse.GroupSet, len(keys))
+	for i, key := range keys {
+		tuples[i] = clickhouse.GroupSet{Value: []any{key.ID, key.ProjectID}}
+	}
+
+	rows, err := s.connection.Query(ctx, getGoodsHistory, tuples, limit)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id, projectID, priority int32
+			value                   models.GoodLog
+		)
+
+		err := rows.Scan(&id, &projectID, &value.Name, &value.Description, &priority, &value.Removed, &value.EventTime, &value.Event)
+		if err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+
+		value.ID, value.ProjectID, value.Priority = int(id), int(projectID), int(priority)
+
+		key := models.GoodKey{ID: value.ID, ProjectID: value.ProjectID}
+		res[key] = append(res[key], value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return res, nil
+}
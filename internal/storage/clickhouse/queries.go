@@ -1,3 +1,14 @@
 package clickhouse
 
 const insertQuery = `INSERT INTO logs`
+
+// getAppliedMigrations reads the versions goose recorded, the initial zero
+// version aside.
+const getAppliedMigrations = `SELECT DISTINCT version_id FROM goose_db_version WHERE is_applied = 1 AND version_id > 0`
+
+// getGoodsHistory keeps the latest events of every requested good.
+const getGoodsHistory = `SELECT Id, ProjectId, Name, Description, Priority, Removed, EventTime, Event
+			FROM logs
+			WHERE (Id, ProjectId) IN (?)
+			ORDER BY EventTime DESC
+			LIMIT ? BY Id, ProjectId`
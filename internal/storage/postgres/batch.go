@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	priorityLockKey = 1
+
+	savepointBatchOperation           = `SAVEPOINT batch_operation`
+	rollbackToSavepointBatchOperation = `ROLLBACK TO SAVEPOINT batch_operation`
+	releaseSavepointBatchOperation    = `RELEASE SAVEPOINT batch_operation`
+)
+
+var (
+	ErrBatchAborted = errors.New("batch operation failed, nothing was applied")
+	ErrUnknownOp    = errors.New("unknown batch operation")
+)
+
+// BatchGoods applies all operations in a single transaction. In atomic mode the
+// first failing operation rolls everything back and ErrBatchAborted is returned
+// along with the results collected so far. In best effort mode every operation
+// runs inside its own savepoint and failures are reported per item.
+func (s *Storage) BatchGoods(ctx context.Context, req *models.BatchRequest) (*[]models.BatchResult, error) {
+	const op = "storage.goods.BatchGoods"
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+	defer tx.Rollback()
+
+	rules, err := getRules(ctx, tx, req.ProjectID)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	priority, err := reservePriorities(ctx, tx, req)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	bestEffort := req.Mode == models.BatchModeBestEffort
+	results := make([]models.BatchResult, 0, len(req.Operations))
+
+	for i, operation := range req.Operations {
+		result := models.BatchResult{Index: i, Op: operation.Op}
+
+		if bestEffort {
+			if _, err := tx.ExecContext(ctx, savepointBatchOperation); err != nil {
+				return nil, wrapper.Wrap(op, err)
+			}
+		}
+
+		good, err := applyBatchOperation(tx, rules, &operation, &priority)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+
+			if !bestEffort {
+				return &results, wrapper.Wrap(op, ErrBatchAborted)
+			}
+
+			if _, err := tx.ExecContext(ctx, rollbackToSavepointBatchOperation); err != nil {
+				return nil, wrapper.Wrap(op, err)
+			}
+			continue
+		}
+
+		if bestEffort {
+			if _, err := tx.ExecContext(ctx, releaseSavepointBatchOperation); err != nil {
+				return nil, wrapper.Wrap(op, err)
+			}
+		}
+
+		result.Good = good
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return &results, nil
+}
+
+// reservePriorities allocates priorities for every create operation at once and
+// returns the last priority in use; creates take the following ones in order.
+func reservePriorities(ctx context.Context, tx *sqlx.Tx, req *models.BatchRequest) (int, error) {
+	creates := 0
+	for _, operation := range req.Operations {
+		if operation.Op == models.BatchOpCreate {
+			creates++
+		}
+	}
+
+	if creates == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, lockPriorities, priorityLockKey); err != nil {
+		return 0, err
+	}
+
+	var priority int
+	if err := tx.QueryRowxContext(ctx, getMaxPriority).Scan(&priority); err != nil {
+		return 0, err
+	}
+
+	return priority, nil
+}
+
+func applyBatchOperation(tx *sqlx.Tx, rules *models.ProjectRules, operation *models.BatchOperation, priority *int) (*models.Good, error) {
+	projectID := rules.ProjectID
+
+	var name, description *string
+	if operation.Name.Valid {
+		name = &operation.Name.Value
+	}
+	if operation.Description.Valid {
+		description = &operation.Description.Value
+	}
+
+	switch operation.Op {
+	case models.BatchOpCreate:
+		*priority++
+
+		if err := checkRules(tx, rules, 0, name, description); err != nil {
+			return nil, err
+		}
+
+		description := sql.NullString{String: operation.Description.Value, Valid: operation.Description.Valid}
+
+		value := models.Good{}
+		row := tx.QueryRowx(createGoodWithDescription, projectID, operation.Name.Value, description, *priority, defaultRemoved)
+		if err := row.StructScan(&value); err != nil {
+			return nil, err
+		}
+
+		return &value, nil
+	case models.BatchOpUpdate:
+		if err := checkRules(tx, rules, operation.ID, name, description); err != nil {
+			return nil, err
+		}
+
+		return applyUpdate(tx, &models.UpdateRequest{
+			ID:          operation.ID,
+			ProjectID:   projectID,
+			Name:        operation.Name,
+			Description: operation.Description,
+			Version:     operation.Version,
+		})
+	case models.BatchOpDelete:
+		return applyDelete(tx, &models.DeleteRequest{
+			ID:        operation.ID,
+			ProjectID: projectID,
+			Version:   operation.Version,
+		})
+	default:
+		return nil, ErrUnknownOp
+	}
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+)
+
+// GetGoodsChanges returns up to filter.Limit changed and purged goods in change
+// order, together with the horizon the filter was applied up to.
+func (s *Storage) GetGoodsChanges(ctx context.Context, filter *models.ChangesFilter) ([]models.GoodChange, int64, error) {
+	const op = "storage.changes.GetGoodsChanges"
+
+	toXID := filter.ToXID
+	if toXID == 0 {
+		if err := s.db.GetContext(ctx, &toXID, getChangesHorizon); err != nil {
+			return nil, 0, wrapper.Wrap(op, err)
+		}
+	}
+
+	args := []any{filter.ProjectID, filter.FromXID, toXID, filter.AfterSeq, filter.Limit}
+
+	goods := make([]models.GoodChange, 0, filter.Limit)
+	if err := s.db.SelectContext(ctx, &goods, getGoodsChanges, args...); err != nil {
+		return nil, 0, wrapper.Wrap(op, err)
+	}
+
+	tombstones := make([]models.GoodChange, 0)
+	if err := s.db.SelectContext(ctx, &tombstones, getTombstonesChanges, args...); err != nil {
+		return nil, 0, wrapper.Wrap(op, err)
+	}
+
+	changes := make([]models.GoodChange, 0, len(goods)+len(tombstones))
+	for len(changes) < filter.Limit && (len(goods) > 0 || len(tombstones) > 0) {
+		if len(tombstones) == 0 || (len(goods) > 0 && goods[0].ChangeSeq < tombstones[0].ChangeSeq) {
+			changes, goods = append(changes, goods[0]), goods[1:]
+		} else {
+			changes, tombstones = append(changes, tombstones[0]), tombstones[1:]
+		}
+	}
+
+	return changes, toXID, nil
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ExportGoods walks the project's goods through a cursor and calls fn for each
+// of them. Iteration stops at the first error returned by fn.
+func (s *Storage) ExportGoods(ctx context.Context, req *models.ExportRequest, fn func(good *models.Good) error) error {
+	const op = "storage.export.ExportGoods"
+
+	tx, err := s.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, declareExportCursor,
+		req.ProjectID, req.IncludeRemoved, likeEscaper.Replace(req.Name), req.CreatedAfter, req.CreatedBefore)
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	for {
+		rows, err := tx.QueryxContext(ctx, fetchExportCursor)
+		if err != nil {
+			return wrapper.Wrap(op, err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			value := models.Good{}
+
+			if err := rows.StructScan(&value); err != nil {
+				rows.Close()
+				return wrapper.Wrap(op, err)
+			}
+			fetched++
+
+			if err := fn(&value); err != nil {
+				rows.Close()
+				return wrapper.Wrap(op, err)
+			}
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return wrapper.Wrap(op, err)
+		}
+
+		if fetched == 0 {
+			return nil
+		}
+	}
+}
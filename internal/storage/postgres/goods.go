@@ -1,12 +1,16 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
 	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/jmoiron/sqlx"
 )
 
 const (
@@ -14,7 +18,9 @@ const (
 )
 
 var (
-	ErrGoodNotFound = errors.New("good with such id in project not found")
+	ErrGoodNotFound    = errors.New("good with such id in project not found")
+	ErrVersionConflict = errors.New("good was modified by another request")
+	ErrTestFailed      = errors.New("good doesn't match patch test operation")
 )
 
 func (s *Storage) Create(req *models.CreateRequest) (*models.Good, error) {
@@ -22,76 +28,120 @@ func (s *Storage) Create(req *models.CreateRequest) (*models.Good, error) {
 
 	var good models.Good
 
-	row := s.db.QueryRow(createGoodQuery, req.ProjectID, req.Name, req.Priority, defaultRemoved)
+	row := s.db.QueryRowx(createGoodQuery, req.ProjectID, req.Name, req.Priority, defaultRemoved)
 
-	if err := row.Scan(&good); err != nil {
+	if err := row.StructScan(&good); err != nil {
 		return nil, wrapper.Wrap(op, err)
 	}
 
 	return &good, nil
 }
 
-func (s *Storage) GetAllGoods() (*[]models.Good, error) {
-	const op = "storage.goods.GetAllGoods"
+func (s *Storage) GetMaxPriority(ctx context.Context) (int, error) {
+	const op = "storage.goods.GetMaxPriority"
 
-	rows, err := s.db.Query(getAllGoods)
+	var priority int
+
+	if err := s.db.GetContext(ctx, &priority, getMaxPriority); err != nil {
+		return 0, wrapper.Wrap(op, err)
+	}
+
+	return priority, nil
+}
+
+func (s *Storage) UpdateGood(req *models.UpdateRequest) (*models.Good, error) {
+	const op = "storage.goods.UpdateGood"
+
+	good, err := applyUpdate(s.db, req)
 	if err != nil {
 		return nil, wrapper.Wrap(op, err)
 	}
-	defer rows.Close()
-
-	var goods []models.Good
 
-	for rows.Next() {
-		value := models.Good{}
+	return good, nil
+}
 
-		if err := rows.Scan(&value); err != nil {
-			return nil, wrapper.Wrap(op, err)
-		}
+func (s *Storage) DeleteGood(req *models.DeleteRequest) (*models.Good, error) {
+	const op = "storage.goods.DeleteGood"
 
-		goods = append(goods, value)
+	good, err := applyDelete(s.db, req)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
 	}
 
-	return &goods, nil
+	return good, nil
 }
 
-func (s *Storage) UpdateGood(req *models.UpdateRequest) (*models.Good, error) {
-	const op = "storage.goods.UpdateGood"
+func (s *Storage) RestoreGood(req *models.RestoreRequest) (*models.Good, error) {
+	const op = "storage.goods.RestoreGood"
 
 	value := models.Good{}
-	row := s.db.QueryRow(getGood, req.ID, req.ProjectID)
-	if err := row.Scan(&value); err != nil {
+	row := s.db.QueryRowx(getGood, req.ID, req.ProjectID)
+	if err := row.StructScan(&value); err != nil {
 		return nil, wrapper.Wrap(op, ErrGoodNotFound)
 	}
 
-	row = s.db.QueryRow(updateGood, req.Name, req.Description, req.ID, req.ProjectID)
-	if err := row.Scan(&value); err != nil {
+	if !value.Removed {
+		return &value, nil
+	}
+
+	row = s.db.QueryRowx(restoreGood, req.ID, req.ProjectID)
+	if err := row.StructScan(&value); err != nil {
 		return nil, wrapper.Wrap(op, err)
 	}
 
 	return &value, nil
 }
 
-func (s *Storage) DeleteGood(req *models.DeleteRequest) (*models.DeleteResponse, error) {
-	const op = "storage.goods.DeleteGood"
+func (s *Storage) PurgeGoods(req *models.PurgeRequest) (*[]models.Good, error) {
+	const op = "storage.goods.PurgeGoods"
 
-	value := models.DeleteResponse{}
-	row := s.db.QueryRow(getGood, req.ID, req.ProjectID)
-	if err := row.Scan(&value); err != nil {
-		return nil, wrapper.Wrap(op, ErrGoodNotFound)
+	rows, err := s.db.Queryx(purgeGoods, req.RemovedBefore, req.ProjectID, req.Limit)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
 	}
+	defer rows.Close()
+
+	goods := make([]models.Good, 0, req.Limit)
 
-	row = s.db.QueryRow(deleteGood, req.ID, req.ProjectID)
-	if err := row.Scan(&value); err != nil {
+	for rows.Next() {
+		value := models.Good{}
+
+		if err := rows.StructScan(&value); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+
+		goods = append(goods, value)
+	}
+
+	if err := rows.Err(); err != nil {
 		return nil, wrapper.Wrap(op, err)
 	}
 
-	return &value, nil
+	return &goods, nil
+}
+
+// GetPurgeableProjects returns the projects with goods removed before
+// removedBefore.
+func (s *Storage) GetPurgeableProjects(ctx context.Context, removedBefore time.Time) ([]int, error) {
+	const op = "storage.goods.GetPurgeableProjects"
+
+	projects := make([]int, 0)
+	if err := s.db.SelectContext(ctx, &projects, getPurgeableProjects, removedBefore); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return projects, nil
 }
 
 func (s *Storage) ListGoods(ids *[]int) (*[]models.Good, error) {
 	const op = "storage.goods.ListGoods"
 
+	var goods []models.Good
+
+	if len(*ids) == 0 {
+		return &goods, nil
+	}
+
 	idsConstraint := strings.Builder{}
 
 	for i, idx := range *ids {
@@ -103,18 +153,16 @@ func (s *Storage) ListGoods(ids *[]int) (*[]models.Good, error) {
 
 	query := fmt.Sprintf(listGoodsWithIds, idsConstraint.String())
 
-	rows, err := s.db.Query(query)
+	rows, err := s.db.Queryx(query)
 	if err != nil {
 		return nil, wrapper.Wrap(op, err)
 	}
 	defer rows.Close()
 
-	var goods []models.Good
-
 	for rows.Next() {
 		value := models.Good{}
 
-		if err := rows.Scan(&value); err != nil {
+		if err := rows.StructScan(&value); err != nil {
 			return nil, wrapper.Wrap(op, err)
 		}
 
@@ -128,12 +176,16 @@ func (s *Storage) ReprioritizeGoods(req *models.ReprioritizeRequest) (*[]models.
 	const op = "storage.goods.ReprioritizeGoods"
 
 	value := models.Good{}
-	row := s.db.QueryRow(getGood, req.ID, req.ProjectID)
-	if err := row.Scan(&value); err != nil {
-		return nil, wrapper.Wrap(op, errors.New("no good with this params"))
+	row := s.db.QueryRowx(getGood, req.ID, req.ProjectID)
+	if err := row.StructScan(&value); err != nil {
+		return nil, wrapper.Wrap(op, ErrGoodNotFound)
+	}
+
+	if req.Version != 0 && req.Version != value.Version {
+		return nil, wrapper.Wrap(op, ErrVersionConflict)
 	}
 
-	rows, err := s.db.Query(reprioritizeGood, req.NewPriority)
+	rows, err := s.db.Queryx(reprioritizeGood, req.NewPriority, req.ID, req.ProjectID, req.Version)
 	if err != nil {
 		return nil, wrapper.Wrap(op, err)
 	}
@@ -144,12 +196,129 @@ func (s *Storage) ReprioritizeGoods(req *models.ReprioritizeRequest) (*[]models.
 	for rows.Next() {
 		value := models.Priorities{}
 
-		if err := rows.Scan(&value); err != nil {
+		if err := rows.StructScan(&value); err != nil {
 			return nil, wrapper.Wrap(op, err)
 		}
 
 		priorities = append(priorities, value)
 	}
 
+	if len(priorities) == 0 {
+		return nil, wrapper.Wrap(op, ErrVersionConflict)
+	}
+
 	return &priorities, nil
 }
+
+func (s *Storage) SearchGoods(req *models.SearchRequest) (*[]models.SearchResult, error) {
+	const op = "storage.goods.SearchGoods"
+
+	rows, err := s.db.Queryx(searchGoods, req.ProjectID, req.Query, req.IncludeRemoved, req.Limit, req.Offset)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0, req.Limit)
+
+	for rows.Next() {
+		value := models.SearchResult{}
+
+		if err := rows.StructScan(&value); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+
+		results = append(results, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return &results, nil
+}
+
+func (s *Storage) SuggestGoods(ctx context.Context, req *models.SuggestRequest) (*[]models.Suggestion, error) {
+	const op = "storage.goods.SuggestGoods"
+
+	rows, err := s.db.QueryxContext(ctx, suggestGoods, req.ProjectID, req.Query, req.Limit)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+	defer rows.Close()
+
+	suggestions := make([]models.Suggestion, 0, req.Limit)
+
+	for rows.Next() {
+		value := models.Suggestion{}
+
+		if err := rows.StructScan(&value); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+
+		suggestions = append(suggestions, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return &suggestions, nil
+}
+
+// applyUpdate and applyDelete run against either the database or a
+// transaction, so single requests and batches share the same checks.
+func applyUpdate(q sqlx.Queryer, req *models.UpdateRequest) (*models.Good, error) {
+	value := models.Good{}
+	row := q.QueryRowx(getGood, req.ID, req.ProjectID)
+	if err := row.StructScan(&value); err != nil {
+		return nil, ErrGoodNotFound
+	}
+
+	if req.Version != 0 && req.Version != value.Version {
+		return nil, ErrVersionConflict
+	}
+
+	if (req.TestName.Set && req.TestName.Value != value.Name) ||
+		(req.TestDescription.Set && req.TestDescription.Value != value.Description) {
+		return nil, ErrTestFailed
+	}
+
+	description := sql.NullString{String: req.Description.Value, Valid: req.Description.Valid}
+
+	row = q.QueryRowx(updateGood,
+		req.Name.Set, req.Name.Value,
+		req.Description.Set, description,
+		req.ID, req.ProjectID, req.Version,
+	)
+	if err := row.StructScan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVersionConflict
+		}
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+func applyDelete(q sqlx.Queryer, req *models.DeleteRequest) (*models.Good, error) {
+	value := models.Good{}
+	row := q.QueryRowx(getGood, req.ID, req.ProjectID)
+	if err := row.StructScan(&value); err != nil || value.Removed {
+		return nil, ErrGoodNotFound
+	}
+
+	if req.Version != 0 && req.Version != value.Version {
+		return nil, ErrVersionConflict
+	}
+
+	row = q.QueryRowx(deleteGood, req.ID, req.ProjectID, req.Version)
+	if err := row.StructScan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVersionConflict
+		}
+		return nil, err
+	}
+
+	return &value, nil
+}
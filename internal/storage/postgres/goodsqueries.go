@@ -1,19 +1,118 @@
 package postgres
 
-const createGoodQuery = `INSERT INTO goods (project_id, name, priority, removed) 
-			VALUES ($1, $2, $3, $4) RETURNING *`
+// goodColumns lists the columns scanned into models.Good. Queries must not use
+// SELECT * because goods also carries service columns (e.g. search_vector).
+const goodColumns = `id, project_id, name, COALESCE(description, '') AS description, priority,
+	removed, removed_at, created_at, version, COALESCE(sku, '') AS sku`
 
-const getAllGoods = `SELECT * FROM goods`
+const createGoodQuery = `INSERT INTO goods (project_id, name, priority, removed)
+			VALUES ($1, $2, $3, $4) RETURNING ` + goodColumns
 
-const getGood = `SELECT * FROM goods WHERE id=$1 AND project_id=$2`
+const createGoodWithDescription = `INSERT INTO goods (project_id, name, description, priority, removed)
+			VALUES ($1, $2, $3, $4, $5) RETURNING ` + goodColumns
 
-const updateGood = `UPDATE goods SET name=$1, description=$2 
-             WHERE id=$3 AND project_id=$4 RETURNING *`
+const getGood = `SELECT ` + goodColumns + ` FROM goods WHERE id=$1 AND project_id=$2`
 
-const deleteGood = `DELETE FROM goods WHERE id=$1 AND project_id=$2 RETURNING id, project_id, removed`
+// updateGood only overwrites the columns whose flag ($1, $3) is set.
+const updateGood = `UPDATE goods SET
+				name = CASE WHEN $1 THEN $2 ELSE name END,
+				description = CASE WHEN $3 THEN $4 ELSE description END,
+				version = version+1
+             WHERE id=$5 AND project_id=$6 AND ($7 = 0 OR version=$7) RETURNING ` + goodColumns
 
-const listGoods = `SELECT * FROM goods ORDER BY id LIMIT $1 OFFSET $2`
+const deleteGood = `UPDATE goods SET removed=true, removed_at=CURRENT_TIMESTAMP, version=version+1
+             WHERE id=$1 AND project_id=$2 AND NOT removed AND ($3 = 0 OR version=$3)
+             RETURNING ` + goodColumns
 
-const listGoodsWithIds = `SELECT * FROM goods WHERE id IN (%s)`
+const restoreGood = `UPDATE goods SET removed=false, removed_at=NULL, version=version+1
+             WHERE id=$1 AND project_id=$2 AND removed RETURNING ` + goodColumns
 
-const reprioritizeGood = `UPDATE goods SET priority = priority+1 WHERE priority >= $1 RETURNING id, priority`
+// purgeGoods permanently deletes one batch of goods of project $2 that were
+// removed before $1.
+const purgeGoods = `DELETE FROM goods WHERE (id, project_id) IN (
+				SELECT id, project_id FROM goods
+				WHERE removed AND removed_at < $1 AND project_id = $2
+				ORDER BY removed_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			) RETURNING ` + goodColumns
+
+const getPurgeableProjects = `SELECT DISTINCT project_id FROM goods
+			WHERE removed AND removed_at < $1 ORDER BY project_id`
+
+const listGoods = `SELECT ` + goodColumns + ` FROM goods ORDER BY id LIMIT $1 OFFSET $2`
+
+const listGoodsWithIds = `SELECT ` + goodColumns + ` FROM goods WHERE id IN (%s)`
+
+// reprioritizeGood moves the good to the new priority and shifts every other good
+// of the project at or above it. Nothing is shifted when the expected version
+// ($4) is stale.
+const reprioritizeGood = `WITH target AS (
+				UPDATE goods SET priority=$1, version=version+1
+				WHERE id=$2 AND project_id=$3 AND ($4 = 0 OR version=$4)
+				RETURNING id, priority, version
+			), shifted AS (
+				UPDATE goods SET priority=priority+1, version=version+1
+				WHERE project_id=$3 AND priority >= $1 AND id<>$2 AND EXISTS (SELECT 1 FROM target)
+				RETURNING id, priority, version
+			)
+			SELECT id, priority, version FROM target
+			UNION ALL
+			SELECT id, priority, version FROM shifted`
+
+// searchGoods matches against both languages at once: the 'russian' text search
+// configuration stems Cyrillic words with russian_stem and Latin words with english_stem.
+const searchGoods = `WITH search AS (
+				SELECT websearch_to_tsquery('russian', $2) AS query
+			)
+			SELECT ` + goodColumns + `,
+				ts_rank(search_vector, search.query) AS rank,
+				ts_headline('russian', name, search.query,
+					'StartSel=<b>, StopSel=</b>, HighlightAll=true') AS name_highlight,
+				ts_headline('russian', COALESCE(description, ''), search.query,
+					'StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=20, MinWords=5') AS description_highlight,
+				COUNT(*) OVER () AS total
+			FROM goods, search
+			WHERE project_id=$1 AND search_vector @@ search.query AND ($3 OR NOT removed)
+			ORDER BY rank DESC, priority
+			LIMIT $4 OFFSET $5`
+
+// suggestGoods relies on the pg_trgm word similarity operator, so partial
+// and misspelled names still match.
+const suggestGoods = `SELECT id, name, word_similarity($2, name) AS score
+			FROM goods
+			WHERE project_id=$1 AND NOT removed AND $2 <% name
+			ORDER BY score DESC, name
+			LIMIT $3`
+
+// lockPriorities serialises priority allocation between batches for the
+// lifetime of the transaction.
+const lockPriorities = `SELECT pg_advisory_xact_lock($1)`
+
+const getMaxPriority = `SELECT COALESCE(MAX(priority), 0) FROM goods`
+
+// declareExportCursor opens a server-side cursor over the project's goods, so
+// exports are read in chunks instead of being loaded at once.
+const declareExportCursor = `DECLARE goods_export NO SCROLL CURSOR FOR
+			SELECT ` + goodColumns + ` FROM goods
+			WHERE project_id=$1 AND ($2 OR NOT removed)
+				AND ($3 = '' OR name ILIKE '%' || $3 || '%')
+				AND ($4::timestamp IS NULL OR created_at >= $4)
+				AND ($5::timestamp IS NULL OR created_at < $5)
+			ORDER BY priority, id`
+
+const fetchExportCursor = `FETCH 500 FROM goods_export`
+
+// getChangesHorizon returns the oldest running transaction: changes of older
+// transactions are committed and can't be joined by lower sequence values.
+const getChangesHorizon = `SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint`
+
+const getGoodsChanges = `SELECT ` + goodColumns + `, change_seq FROM goods
+			WHERE project_id=$1 AND change_xid >= $2::text::xid8 AND change_xid < $3::text::xid8 AND change_seq > $4
+			ORDER BY change_seq
+			LIMIT $5`
+
+const getTombstonesChanges = `SELECT id, project_id, change_seq, true AS purged FROM goods_tombstones
+			WHERE project_id=$1 AND change_xid >= $2::text::xid8 AND change_xid < $3::text::xid8 AND change_seq > $4
+			ORDER BY change_seq
+			LIMIT $5`
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/migrations"
+)
+
+// getAppliedMigrations reads the versions goose recorded, the initial zero
+// version aside.
+const getAppliedMigrations = `SELECT DISTINCT version_id FROM goose_db_version WHERE is_applied AND version_id > 0`
+
+var (
+	ErrMigrationsPending = errors.New("migrations are not applied")
+)
+
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "storage.postgres.Ping"
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// CheckMigrations compares the goose migrations in fsys with the versions
+// applied to the database and returns ErrMigrationsPending listing the missing
+// ones. Only the top level of fsys is read: migrations of other databases kept
+// in subdirectories are never expected here.
+func (s *Storage) CheckMigrations(ctx context.Context, fsys fs.FS) error {
+	const op = "storage.postgres.CheckMigrations"
+
+	var applied []int64
+	if err := s.db.SelectContext(ctx, &applied, getAppliedMigrations); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	pending, err := migrations.Pending(fsys, applied)
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	if len(pending) > 0 {
+		return wrapper.Wrap(op, fmt.Errorf("%w: %s", ErrMigrationsPending, strings.Join(pending, ", ")))
+	}
+
+	return nil
+}
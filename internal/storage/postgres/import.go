@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUnknownImportKey = errors.New("unknown import key")
+)
+
+// GoodsImport streams rows into a temporary table with COPY and merges them into
+// goods on Commit, so that files of any size are never held in memory.
+type GoodsImport struct {
+	tx        *sqlx.Tx
+	stmt      *sql.Stmt
+	projectID int
+}
+
+func (s *Storage) BeginImport(ctx context.Context, projectID int) (*GoodsImport, error) {
+	const op = "storage.import.BeginImport"
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, createImportTable); err != nil {
+		_ = tx.Rollback()
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(importTable, "line", "name", "description", "sku"))
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return &GoodsImport{tx: tx, stmt: stmt, projectID: projectID}, nil
+}
+
+func (i *GoodsImport) Add(ctx context.Context, row *models.ImportRow) error {
+	const op = "storage.import.Add"
+
+	description := sql.NullString{String: row.Description, Valid: row.Description != ""}
+	sku := sql.NullString{String: row.SKU, Valid: row.SKU != ""}
+
+	if _, err := i.stmt.ExecContext(ctx, row.Line, row.Name, description, sku); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+// Commit merges the copied rows into goods and returns the merged goods. With
+// dryRun the transaction is rolled back after the merge, so the counts are real
+// but nothing is persisted.
+func (i *GoodsImport) Commit(ctx context.Context, req *models.ImportRequest) (*models.ImportResult, error) {
+	const op = "storage.import.Commit"
+
+	defer i.tx.Rollback()
+
+	if _, err := i.stmt.ExecContext(ctx); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	if err := i.stmt.Close(); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	if _, err := i.tx.ExecContext(ctx, lockPriorities, priorityLockKey); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	var priority int
+	if err := i.tx.QueryRowxContext(ctx, getMaxPriority).Scan(&priority); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	result := &models.ImportResult{}
+
+	// the goods a row updates in upsert mode keep their SKU
+	target, duplicate := "false", "false"
+
+	switch req.Mode {
+	case models.ImportModeUpsert:
+		if req.Key != models.ImportKeyName && req.Key != models.ImportKeySKU {
+			return nil, wrapper.Wrap(op, ErrUnknownImportKey)
+		}
+
+		target = fmt.Sprintf("g.%[1]s = i.%[1]s", req.Key)
+		duplicate = fmt.Sprintf("d.%[1]s = i.%[1]s", req.Key)
+	}
+
+	rules, err := getRules(ctx, i.tx, i.projectID)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+	result.Rules = *rules
+
+	if rules.MaxDescriptionLength > 0 {
+		if err := i.reject(ctx, result, "description", rejectImportDescriptions, rules.MaxDescriptionLength); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+	}
+
+	if rules.UniqueNames {
+		if err := i.reject(ctx, result, "name", fmt.Sprintf(rejectImportNames, target, duplicate), i.projectID); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+	}
+
+	if err := i.reject(ctx, result, "sku", fmt.Sprintf(rejectImportSKUs, target, duplicate), i.projectID); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	switch req.Mode {
+	case models.ImportModeUpsert:
+		dedup := fmt.Sprintf(dedupImport, req.Key)
+
+		if err := i.tx.SelectContext(ctx, &result.Updated, fmt.Sprintf(upsertUpdateImport, req.Key, dedup), i.projectID); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+
+		if err := i.tx.SelectContext(ctx, &result.Inserted, fmt.Sprintf(upsertInsertImport, req.Key, dedup), i.projectID, priority); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+	default:
+		if err := i.tx.SelectContext(ctx, &result.Inserted, insertImport, i.projectID, priority); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+	}
+
+	if req.DryRun {
+		return result, nil
+	}
+
+	if err := i.tx.Commit(); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return result, nil
+}
+
+// reject drops the rows matched by query from the import and adds them to the
+// result as rejected on field.
+func (i *GoodsImport) reject(ctx context.Context, result *models.ImportResult, field, query string, args ...any) error {
+	var rows []models.ImportRejection
+	if err := i.tx.SelectContext(ctx, &rows, query, args...); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		row.Field = field
+		result.Rejected = append(result.Rejected, row)
+	}
+
+	return nil
+}
+
+func (i *GoodsImport) Rollback() error {
+	const op = "storage.import.Rollback"
+
+	_ = i.stmt.Close()
+
+	if err := i.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
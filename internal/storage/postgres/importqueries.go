@@ -0,0 +1,68 @@
+package postgres
+
+const createImportTable = `CREATE TEMP TABLE goods_import (
+				line INT NOT NULL,
+				name VARCHAR(255) NOT NULL,
+				description TEXT,
+				sku VARCHAR(255)
+			) ON COMMIT DROP`
+
+const importTable = "goods_import"
+
+// dedupImport keeps the last row per key, so a file that repeats a key behaves
+// as if its rows were applied one after another. %[1]s is the key column.
+const dedupImport = `SELECT DISTINCT ON (%[1]s) line, name, description, sku
+			FROM goods_import ORDER BY %[1]s, line DESC`
+
+// importedGoodColumns are goodColumns of the goods g updated from the import.
+const importedGoodColumns = `g.id, g.project_id, g.name, COALESCE(g.description, '') AS description, g.priority,
+	g.removed, g.removed_at, g.created_at, g.version, COALESCE(g.sku, '') AS sku`
+
+const rejectedImportColumns = `line, name, COALESCE(description, '') AS description, COALESCE(sku, '') AS sku`
+
+// The reject queries drop the rows that would break the project rules or the
+// unique SKUs and return them, so that they are reported instead of failing
+// the whole import. %[1]s tells whether good g is the one the row updates,
+// %[2]s whether row d updates the same good; both are false in insert mode.
+
+const rejectImportSKUs = `DELETE FROM goods_import i
+			WHERE i.sku IS NOT NULL AND (
+				EXISTS (SELECT 1 FROM goods g WHERE g.project_id=$1 AND g.sku=i.sku AND NOT (%[1]s))
+				OR EXISTS (SELECT 1 FROM goods_import d WHERE d.sku=i.sku AND d.line<i.line AND NOT (%[2]s))
+			)
+			RETURNING ` + rejectedImportColumns
+
+const rejectImportNames = `DELETE FROM goods_import i
+			WHERE EXISTS (SELECT 1 FROM goods g WHERE g.project_id=$1 AND g.name=i.name AND NOT g.removed AND NOT (%[1]s))
+				OR EXISTS (SELECT 1 FROM goods_import d WHERE d.name=i.name AND d.line<i.line AND NOT (%[2]s))
+			RETURNING ` + rejectedImportColumns
+
+const rejectImportDescriptions = `DELETE FROM goods_import
+			WHERE char_length(description) > $1
+			RETURNING ` + rejectedImportColumns
+
+// upsertUpdateImport updates matching goods, restoring removed ones.
+// %[1]s is the key column, %[2]s is dedupImport.
+const upsertUpdateImport = `UPDATE goods g SET
+				name = i.name,
+				description = i.description,
+				sku = COALESCE(i.sku, g.sku),
+				removed = false,
+				removed_at = NULL,
+				version = g.version+1
+			FROM (%[2]s) i
+			WHERE g.project_id=$1 AND g.%[1]s = i.%[1]s
+			RETURNING ` + importedGoodColumns
+
+// upsertInsertImport inserts rows with no matching good. Priorities continue
+// from $2 in file order. %[1]s is the key column, %[2]s is dedupImport.
+const upsertInsertImport = `INSERT INTO goods (project_id, name, description, sku, priority, removed)
+			SELECT $1, i.name, i.description, i.sku, $2 + ROW_NUMBER() OVER (ORDER BY i.line), false
+			FROM (%[2]s) i
+			WHERE NOT EXISTS (SELECT 1 FROM goods g WHERE g.project_id=$1 AND g.%[1]s = i.%[1]s)
+			RETURNING ` + goodColumns
+
+const insertImport = `INSERT INTO goods (project_id, name, description, sku, priority, removed)
+			SELECT $1, name, description, sku, $2 + ROW_NUMBER() OVER (ORDER BY line), false
+			FROM goods_import
+			RETURNING ` + goodColumns
@@ -28,8 +28,19 @@ func NewStorage(log *slog.Logger, cfg config.Storage) (*Storage, error) {
 	}
 
 	if err = db.Ping(); err != nil {
+		_ = db.Close()
 		return nil, wrapper.Wrap(op, err)
 	}
 
 	return &Storage{log: log, db: db}, nil
 }
+
+func (s *Storage) Close() error {
+	const op = "storage.postgres.Close"
+
+	if err := s.db.Close(); err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrProjectNotFound    = errors.New("project with such id not found")
+	ErrNameTaken          = errors.New("good with such name already exists in project")
+	ErrDescriptionTooLong = errors.New("description is longer than project rules allow")
+)
+
+func (s *Storage) GetProjectRules(ctx context.Context, projectID int) (*models.ProjectRules, error) {
+	const op = "storage.project.GetProjectRules"
+
+	var rules models.ProjectRules
+
+	row := s.db.QueryRowxContext(ctx, getProjectRules, projectID)
+	if err := row.StructScan(&rules); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wrapper.Wrap(op, ErrProjectNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return &rules, nil
+}
+
+func (s *Storage) SaveProjectRules(ctx context.Context, rules *models.ProjectRules) (*models.ProjectRules, error) {
+	const op = "storage.project.SaveProjectRules"
+
+	if _, err := s.GetProjectRules(ctx, rules.ProjectID); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	var saved models.ProjectRules
+
+	row := s.db.QueryRowxContext(ctx, saveProjectRules, rules.ProjectID, rules.UniqueNames, rules.MaxDescriptionLength)
+	if err := row.StructScan(&saved); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return &saved, nil
+}
+
+// checkRules applies the project rules to the name and description a good is
+// about to get inside tx, so that it sees the earlier writes of tx. A nil field
+// isn't checked.
+func checkRules(tx *sqlx.Tx, rules *models.ProjectRules, id int, name, description *string) error {
+	if description != nil && rules.MaxDescriptionLength > 0 && utf8.RuneCountInString(*description) > rules.MaxDescriptionLength {
+		return ErrDescriptionTooLong
+	}
+
+	if name != nil && rules.UniqueNames {
+		var exists bool
+		if err := tx.Get(&exists, goodNameExists, rules.ProjectID, *name, id); err != nil {
+			return err
+		}
+
+		if exists {
+			return ErrNameTaken
+		}
+	}
+
+	return nil
+}
+
+func getRules(ctx context.Context, tx *sqlx.Tx, projectID int) (*models.ProjectRules, error) {
+	var rules models.ProjectRules
+
+	if err := tx.GetContext(ctx, &rules, getProjectRules, projectID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+func (s *Storage) GoodNameExists(ctx context.Context, projectID int, name string, excludeID int) (bool, error) {
+	const op = "storage.project.GoodNameExists"
+
+	var exists bool
+
+	if err := s.db.GetContext(ctx, &exists, goodNameExists, projectID, name, excludeID); err != nil {
+		return false, wrapper.Wrap(op, err)
+	}
+
+	return exists, nil
+}
+
+func (s *Storage) GetProjects(ctx context.Context, ids []int) (map[int]*models.Project, error) {
+	const op = "storage.project.GetProjects"
+
+	var projects []models.Project
+
+	if err := s.db.SelectContext(ctx, &projects, getProjects, pq.Array(ids)); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	res := make(map[int]*models.Project, len(projects))
+	for i := range projects {
+		res[projects[i].ID] = &projects[i]
+	}
+
+	return res, nil
+}
+
+func (s *Storage) ListProjects(ctx context.Context, limit, offset int) ([]models.Project, error) {
+	const op = "storage.project.ListProjects"
+
+	projects := make([]models.Project, 0, limit)
+
+	if err := s.db.SelectContext(ctx, &projects, listProjects, limit, offset); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return projects, nil
+}
+
+func (s *Storage) GetProjectsRules(ctx context.Context, ids []int) (map[int]*models.ProjectRules, error) {
+	const op = "storage.project.GetProjectsRules"
+
+	var rules []models.ProjectRules
+
+	if err := s.db.SelectContext(ctx, &rules, getProjectsRules, pq.Array(ids)); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	res := make(map[int]*models.ProjectRules, len(rules))
+	for i := range rules {
+		res[rules[i].ProjectID] = &rules[i]
+	}
+
+	return res, nil
+}
+
+// GetProjectsGoods returns a page of goods for each of the projects. Projects
+// without matching goods get an empty page.
+func (s *Storage) GetProjectsGoods(ctx context.Context, ids []int, filter *models.ProjectGoodsFilter) (map[int]*models.GoodsPage, error) {
+	const op = "storage.project.GetProjectsGoods"
+
+	res := make(map[int]*models.GoodsPage, len(ids))
+	for _, id := range ids {
+		res[id] = &models.GoodsPage{Goods: []models.Good{}}
+	}
+
+	rows, err := s.db.QueryContext(ctx, countProjectsGoods, pq.Array(ids), filter.IncludeRemoved, filter.Name)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectID, total int
+		if err := rows.Scan(&projectID, &total); err != nil {
+			return nil, wrapper.Wrap(op, err)
+		}
+
+		if page, ok := res[projectID]; ok {
+			page.Total = total
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	var goods []models.Good
+
+	err = s.db.SelectContext(ctx, &goods, getProjectsGoods,
+		pq.Array(ids), filter.IncludeRemoved, filter.Name, filter.Offset, filter.Limit)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	for _, good := range goods {
+		if page, ok := res[good.ProjectID]; ok {
+			page.Goods = append(page.Goods, good)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *Storage) GetGoodsByKeys(ctx context.Context, keys []models.GoodKey) (map[models.GoodKey]*models.Good, error) {
+	const op = "storage.project.GetGoodsByKeys"
+
+	ids := make([]int, len(keys))
+	projectIDs := make([]int, len(keys))
+	for i, key := range keys {
+		ids[i] = key.ID
+		projectIDs[i] = key.ProjectID
+	}
+
+	var goods []models.Good
+
+	if err := s.db.SelectContext(ctx, &goods, getGoodsByKeys, pq.Array(ids), pq.Array(projectIDs)); err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	res := make(map[models.GoodKey]*models.Good, len(goods))
+	for i := range goods {
+		res[models.GoodKey{ID: goods[i].ID, ProjectID: goods[i].ProjectID}] = &goods[i]
+	}
+
+	return res, nil
+}
@@ -0,0 +1,49 @@
+package postgres
+
+// getProjectRules falls back to the defaults for projects without saved rules.
+const getProjectRules = `SELECT p.id AS project_id,
+				COALESCE(r.unique_names, false) AS unique_names,
+				COALESCE(r.max_description_length, 0) AS max_description_length
+			FROM projects p LEFT JOIN project_rules r ON r.project_id = p.id
+			WHERE p.id=$1`
+
+const saveProjectRules = `INSERT INTO project_rules (project_id, unique_names, max_description_length)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (project_id) DO UPDATE SET
+				unique_names = EXCLUDED.unique_names,
+				max_description_length = EXCLUDED.max_description_length
+			RETURNING project_id, unique_names, max_description_length`
+
+// goodNameExists ignores removed goods and the good being updated ($3).
+const goodNameExists = `SELECT EXISTS (
+				SELECT 1 FROM goods WHERE project_id=$1 AND name=$2 AND id<>$3 AND NOT removed
+			)`
+
+const getProjects = `SELECT id, name, created_at FROM projects WHERE id = ANY($1)`
+
+const listProjects = `SELECT id, name, created_at FROM projects ORDER BY id LIMIT $1 OFFSET $2`
+
+const getProjectsRules = `SELECT p.id AS project_id,
+				COALESCE(r.unique_names, false) AS unique_names,
+				COALESCE(r.max_description_length, 0) AS max_description_length
+			FROM projects p LEFT JOIN project_rules r ON r.project_id = p.id
+			WHERE p.id = ANY($1)`
+
+// getProjectsGoods pages through every requested project at once, so nested
+// goods of many projects take a single query.
+const getProjectsGoods = `SELECT ` + goodColumns + ` FROM (
+				SELECT *, ROW_NUMBER() OVER (PARTITION BY project_id ORDER BY priority, id) AS row_number
+				FROM goods
+				WHERE project_id = ANY($1) AND ($2 OR NOT removed)
+					AND ($3 = '' OR name ILIKE '%' || $3 || '%')
+			) g
+			WHERE row_number > $4 AND row_number <= $4 + $5
+			ORDER BY project_id, row_number`
+
+const countProjectsGoods = `SELECT project_id, COUNT(*) FROM goods
+			WHERE project_id = ANY($1) AND ($2 OR NOT removed)
+				AND ($3 = '' OR name ILIKE '%' || $3 || '%')
+			GROUP BY project_id`
+
+const getGoodsByKeys = `SELECT ` + goodColumns + ` FROM goods
+			WHERE (id, project_id) IN (SELECT * FROM unnest($1::int[], $2::int[]))`
@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/IskanderSh/hezzl-task/internal/lib/error/wrapper"
+	"github.com/IskanderSh/hezzl-task/internal/models"
+	"github.com/lib/pq"
+)
+
+const foreignKeyViolation = "23503"
+
+var (
+	ErrWebhookNotFound = errors.New("webhook with such id in project not found")
+)
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	const op = "storage.webhook.CreateWebhook"
+
+	row := s.db.QueryRowContext(ctx, createWebhook,
+		webhook.ProjectID, webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Enabled)
+
+	created, err := scanWebhook(row)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return nil, wrapper.Wrap(op, ErrProjectNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return created, nil
+}
+
+func (s *Storage) ListWebhooks(ctx context.Context, projectID int) ([]models.Webhook, error) {
+	const op = "storage.webhook.ListWebhooks"
+
+	webhooks, err := s.queryWebhooks(ctx, listWebhooks, projectID)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *Storage) GetWebhook(ctx context.Context, projectID, id int) (*models.Webhook, error) {
+	const op = "storage.webhook.GetWebhook"
+
+	webhook, err := scanWebhook(s.db.QueryRowContext(ctx, getWebhook, id, projectID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wrapper.Wrap(op, ErrWebhookNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Storage) UpdateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	const op = "storage.webhook.UpdateWebhook"
+
+	row := s.db.QueryRowContext(ctx, updateWebhook, webhook.ID, webhook.ProjectID,
+		webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Enabled)
+
+	updated, err := scanWebhook(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wrapper.Wrap(op, ErrWebhookNotFound)
+		}
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return updated, nil
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, projectID, id int) error {
+	const op = "storage.webhook.DeleteWebhook"
+
+	res, err := s.db.ExecContext(ctx, deleteWebhook, id, projectID)
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	if affected == 0 {
+		return wrapper.Wrap(op, ErrWebhookNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) ListActiveWebhooks(ctx context.Context, projectIDs []int) ([]models.Webhook, error) {
+	const op = "storage.webhook.ListActiveWebhooks"
+
+	webhooks, err := s.queryWebhooks(ctx, listActiveWebhooks, pq.Array(projectIDs))
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return webhooks, nil
+}
+
+// RecordWebhookResult updates the failure count after a delivery and reports
+// whether the webhook is still enabled.
+func (s *Storage) RecordWebhookResult(ctx context.Context, id int, success bool, disableAfter int) (bool, error) {
+	const op = "storage.webhook.RecordWebhookResult"
+
+	var enabled bool
+
+	if err := s.db.GetContext(ctx, &enabled, recordWebhookResult, id, success, disableAfter); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, wrapper.Wrap(op, ErrWebhookNotFound)
+		}
+		return false, wrapper.Wrap(op, err)
+	}
+
+	return enabled, nil
+}
+
+func (s *Storage) SaveWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	const op = "storage.webhook.SaveWebhookDelivery"
+
+	_, err := s.db.ExecContext(ctx, saveWebhookDelivery, delivery.WebhookID, delivery.EventID, delivery.Event,
+		delivery.Attempt, delivery.Success, delivery.StatusCode, delivery.Error, delivery.DurationMS)
+	if err != nil {
+		return wrapper.Wrap(op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ListWebhookDeliveries(ctx context.Context, projectID, webhookID, limit, offset int) ([]models.WebhookDelivery, error) {
+	const op = "storage.webhook.ListWebhookDeliveries"
+
+	deliveries := make([]models.WebhookDelivery, 0, limit)
+
+	err := s.db.SelectContext(ctx, &deliveries, listWebhookDeliveries, webhookID, projectID, limit, offset)
+	if err != nil {
+		return nil, wrapper.Wrap(op, err)
+	}
+
+	return deliveries, nil
+}
+
+func (s *Storage) queryWebhooks(ctx context.Context, query string, args ...any) ([]models.Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]models.Webhook, 0)
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// scanWebhook scans by hand because sqlx can't map TEXT[] to []string.
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+
+	err := row.Scan(&webhook.ID, &webhook.ProjectID, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events),
+		&webhook.Enabled, &webhook.FailureCount, &webhook.DisabledAt, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
@@ -0,0 +1,45 @@
+package postgres
+
+const webhookColumns = `id, project_id, url, secret, events, enabled, failure_count, disabled_at, created_at`
+
+const createWebhook = `INSERT INTO webhooks (project_id, url, secret, events, enabled)
+			VALUES ($1, $2, $3, $4, $5) RETURNING ` + webhookColumns
+
+const listWebhooks = `SELECT ` + webhookColumns + ` FROM webhooks WHERE project_id=$1 ORDER BY id`
+
+const getWebhook = `SELECT ` + webhookColumns + ` FROM webhooks WHERE id=$1 AND project_id=$2`
+
+// updateWebhook keeps the secret when $4 is empty. Enabling a webhook gives it
+// a fresh failure budget.
+const updateWebhook = `UPDATE webhooks SET
+				url = $3,
+				secret = CASE WHEN $4 = '' THEN secret ELSE $4 END,
+				events = $5,
+				failure_count = CASE WHEN $6 AND NOT enabled THEN 0 ELSE failure_count END,
+				disabled_at = CASE WHEN $6 THEN NULL ELSE COALESCE(disabled_at, CURRENT_TIMESTAMP) END,
+				enabled = $6
+			WHERE id=$1 AND project_id=$2 RETURNING ` + webhookColumns
+
+const deleteWebhook = `DELETE FROM webhooks WHERE id=$1 AND project_id=$2`
+
+const listActiveWebhooks = `SELECT ` + webhookColumns + ` FROM webhooks WHERE enabled AND project_id = ANY($1)`
+
+// recordWebhookResult resets the failure count on success and disables the
+// webhook once $3 deliveries in a row have failed.
+const recordWebhookResult = `UPDATE webhooks SET
+				failure_count = CASE WHEN $2 THEN 0 ELSE failure_count + 1 END,
+				enabled = enabled AND ($2 OR failure_count + 1 < $3),
+				disabled_at = CASE WHEN enabled AND NOT $2 AND failure_count + 1 >= $3
+					THEN CURRENT_TIMESTAMP ELSE disabled_at END
+			WHERE id=$1 RETURNING enabled`
+
+const saveWebhookDelivery = `INSERT INTO webhook_deliveries
+				(webhook_id, event_id, event, attempt, success, status_code, error, duration_ms)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+const listWebhookDeliveries = `SELECT d.id, d.webhook_id, d.event_id, d.event, d.attempt, d.success,
+				d.status_code, d.error, d.duration_ms, d.created_at
+			FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.webhook_id=$1 AND w.project_id=$2
+			ORDER BY d.id DESC
+			LIMIT $3 OFFSET $4`
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- 'russian' configuration stems Cyrillic words with russian_stem
+-- and Latin words with english_stem, so mixed-language names are covered.
+CREATE OR REPLACE FUNCTION goods_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('russian', COALESCE(NEW.name, '')), 'A') ||
+        setweight(to_tsvector('russian', COALESCE(NEW.description, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER goods_search_vector_trigger
+BEFORE INSERT OR UPDATE OF name, description ON goods
+FOR EACH ROW EXECUTE FUNCTION goods_search_vector_update();
+
+UPDATE goods SET search_vector =
+    setweight(to_tsvector('russian', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(description, '')), 'B');
+
+CREATE INDEX IF NOT EXISTS goods_search_vector_idx ON goods USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS goods_search_vector_idx;
+
+DROP TRIGGER IF EXISTS goods_search_vector_trigger ON goods;
+
+DROP FUNCTION IF EXISTS goods_search_vector_update();
+
+ALTER TABLE goods DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
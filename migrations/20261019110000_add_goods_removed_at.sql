@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS removed_at TIMESTAMP;
+
+UPDATE goods SET removed_at = CURRENT_TIMESTAMP WHERE removed AND removed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS goods_removed_at_idx ON goods (removed_at) WHERE removed;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS goods_removed_at_idx;
+
+ALTER TABLE goods DROP COLUMN IF EXISTS removed_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS sku VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS goods_project_sku_idx ON goods (project_id, sku) WHERE sku IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS goods_project_name_idx ON goods (project_id, name);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS goods_project_name_idx;
+
+DROP INDEX IF EXISTS goods_project_sku_idx;
+
+ALTER TABLE goods DROP COLUMN IF EXISTS sku;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS project_rules (
+    project_id INT PRIMARY KEY REFERENCES projects (id) ON DELETE CASCADE,
+    unique_names BOOL NOT NULL DEFAULT false,
+    max_description_length INT NOT NULL DEFAULT 0 CHECK (max_description_length >= 0)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS project_rules;
+-- +goose StatementEnd
@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE SEQUENCE IF NOT EXISTS goods_change_seq;
+
+-- change_xid is the writing transaction: sequence values are taken before
+-- commit, so only changes of transactions below the snapshot xmin are final.
+ALTER TABLE goods
+    ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT nextval('goods_change_seq'),
+    ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE OR REPLACE FUNCTION goods_change_seq_update() RETURNS trigger AS $$
+BEGIN
+    NEW.change_seq := nextval('goods_change_seq');
+    NEW.change_xid := pg_current_xact_id();
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER goods_change_seq_trigger
+BEFORE INSERT OR UPDATE ON goods
+FOR EACH ROW EXECUTE FUNCTION goods_change_seq_update();
+
+CREATE INDEX IF NOT EXISTS goods_project_change_seq_idx ON goods (project_id, change_seq);
+
+-- goods_tombstones remembers purged goods, so that syncing clients delete them.
+CREATE TABLE IF NOT EXISTS goods_tombstones (
+    id INT NOT NULL,
+    project_id INT NOT NULL,
+    change_seq BIGINT NOT NULL DEFAULT nextval('goods_change_seq'),
+    change_xid xid8 NOT NULL DEFAULT pg_current_xact_id(),
+    deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, project_id)
+);
+
+CREATE INDEX IF NOT EXISTS goods_tombstones_project_change_seq_idx ON goods_tombstones (project_id, change_seq);
+
+CREATE OR REPLACE FUNCTION goods_tombstone_insert() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO goods_tombstones (id, project_id) VALUES (OLD.id, OLD.project_id)
+    ON CONFLICT (id, project_id) DO UPDATE SET
+        change_seq = nextval('goods_change_seq'),
+        change_xid = pg_current_xact_id(),
+        deleted_at = CURRENT_TIMESTAMP;
+    RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER goods_tombstone_trigger
+AFTER DELETE ON goods
+FOR EACH ROW EXECUTE FUNCTION goods_tombstone_insert();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS goods_tombstone_trigger ON goods;
+
+DROP FUNCTION IF EXISTS goods_tombstone_insert();
+
+DROP TABLE IF EXISTS goods_tombstones;
+
+DROP TRIGGER IF EXISTS goods_change_seq_trigger ON goods;
+
+DROP FUNCTION IF EXISTS goods_change_seq_update();
+
+DROP INDEX IF EXISTS goods_project_change_seq_idx;
+
+ALTER TABLE goods DROP COLUMN IF EXISTS change_xid, DROP COLUMN IF EXISTS change_seq;
+
+DROP SEQUENCE IF EXISTS goods_change_seq;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE logs ADD COLUMN IF NOT EXISTS Event VARCHAR(32) DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE logs DROP COLUMN IF EXISTS Event;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- The first migration created logs without a table engine and with a
+-- TIMESTAMP default; the table is rebuilt as a MergeTree and the logs copied.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS logs_merge_tree (
+    Id INT,
+    ProjectId INT,
+    Name VARCHAR(255) NOT NULL,
+    Description TEXT,
+    Priority INT,
+    Removed BOOL NOT NULL,
+    EventTime DateTime NOT NULL DEFAULT now(),
+    Event VARCHAR(32) DEFAULT ''
+) ENGINE = MergeTree
+ORDER BY (ProjectId, Id, EventTime);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO logs_merge_tree (Id, ProjectId, Name, Description, Priority, Removed, EventTime, Event)
+SELECT Id, ProjectId, Name, Description, Priority, Removed, EventTime, Event FROM logs;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+RENAME TABLE logs TO logs_previous, logs_merge_tree TO logs;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE logs_previous;
+-- +goose StatementEnd
+
+-- +goose Down
+-- The previous table had no usable engine, so the MergeTree one is kept.
@@ -0,0 +1,63 @@
+// Package migrations embeds the goose migrations, so that the application can
+// tell whether the database schema is up to date.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS holds the Postgres migrations only. The ClickHouse ones live in
+// clickhouse/ with their own goose target and version table, so the pattern
+// deliberately doesn't reach into subdirectories.
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed clickhouse/*.sql
+var clickhouseFS embed.FS
+
+// ClickHouseFS holds the ClickHouse migrations at its root, laid out like FS.
+var ClickHouseFS = mustSub(clickhouseFS, "clickhouse")
+
+// Pending returns the versions of the migrations at the root of migrations
+// that aren't among the applied ones, in file order.
+func Pending(migrations fs.FS, applied []int64) ([]string, error) {
+	files, err := fs.Glob(migrations, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int64]struct{}, len(applied))
+	for _, version := range applied {
+		versions[version] = struct{}{}
+	}
+
+	var pending []string
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(file, "_")
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+
+		if _, ok := versions[version]; !ok {
+			pending = append(pending, prefix)
+		}
+	}
+
+	return pending, nil
+}
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+
+	return sub
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPending(t *testing.T) {
+	fsys := fstest.MapFS{
+		"1_first.sql":            {},
+		"2_second.sql":           {},
+		"3_third.sql":            {},
+		"clickhouse/4_other.sql": {},
+		"README.md":              {},
+	}
+
+	pending, err := Pending(fsys, []int64{1, 3})
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if want := []string{"2"}; !reflect.DeepEqual(pending, want) {
+		t.Fatalf("pending = %v, want %v", pending, want)
+	}
+
+	if _, err := Pending(fstest.MapFS{"first.sql": {}}, nil); err == nil {
+		t.Fatal("expected an error for a migration without a version")
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	for name, fsys := range map[string]fs.FS{"postgres": FS, "clickhouse": ClickHouseFS} {
+		files, err := fs.Glob(fsys, "*.sql")
+		if err != nil || len(files) == 0 {
+			t.Fatalf("%s: files = %v, err = %v", name, files, err)
+		}
+
+		pending, err := Pending(fsys, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(pending) != len(files) {
+			t.Fatalf("%s: pending = %d, want %d", name, len(pending), len(files))
+		}
+	}
+}